@@ -58,3 +58,51 @@
     {{- print "autoscaling.k8s.io/v1beta2" -}}
   {{- end -}}
 {{- end -}}
+
+{{/* Get CronJob API Version */}}
+{{- define "gruntwork.cronJob.apiVersion" -}}
+  {{- if and (.Capabilities.APIVersions.Has "batch/v1") (semverCompare ">= 1.21-0" (include "gruntwork.kubeVersion" .)) -}}
+    {{- print "batch/v1" -}}
+  {{- else -}}
+    {{- print "batch/v1beta1" -}}
+  {{- end -}}
+{{- end -}}
+
+{{/*
+Get Gateway API Version. The Gateway API is installed as CRDs rather than shipped with Kubernetes, so we prefer the
+versions served by the cluster, and only fall back to the Kubernetes version when they are not known (e.g when
+rendering with helm template).
+*/}}
+{{- define "gruntwork.gateway.apiVersion" -}}
+  {{- if .Capabilities.APIVersions.Has "gateway.networking.k8s.io/v1" -}}
+    {{- print "gateway.networking.k8s.io/v1" -}}
+  {{- else if .Capabilities.APIVersions.Has "gateway.networking.k8s.io/v1beta1" -}}
+    {{- print "gateway.networking.k8s.io/v1beta1" -}}
+  {{- else if semverCompare ">= 1.26-0" (include "gruntwork.kubeVersion" .) -}}
+    {{- print "gateway.networking.k8s.io/v1" -}}
+  {{- else -}}
+    {{- print "gateway.networking.k8s.io/v1beta1" -}}
+  {{- end -}}
+{{- end -}}
+
+{{/* Get GRPCRoute API Version. GRPCRoute was only promoted to v1 after HTTPRoute, and was never served as v1beta1. */}}
+{{- define "gruntwork.gateway.grpcRoute.apiVersion" -}}
+  {{- if .Capabilities.APIVersions.Has "gateway.networking.k8s.io/v1/GRPCRoute" -}}
+    {{- print "gateway.networking.k8s.io/v1" -}}
+  {{- else if .Capabilities.APIVersions.Has "gateway.networking.k8s.io/v1alpha2/GRPCRoute" -}}
+    {{- print "gateway.networking.k8s.io/v1alpha2" -}}
+  {{- else if eq (include "gruntwork.gateway.apiVersion" .) "gateway.networking.k8s.io/v1" -}}
+    {{- print "gateway.networking.k8s.io/v1" -}}
+  {{- else -}}
+    {{- print "gateway.networking.k8s.io/v1alpha2" -}}
+  {{- end -}}
+{{- end -}}
+
+{{/* Get ManagedCertificate API Version */}}
+{{- define "gruntwork.managedCertificate.apiVersion" -}}
+  {{- if .Capabilities.APIVersions.Has "networking.gke.io/v1/ManagedCertificate" -}}
+    {{- print "networking.gke.io/v1" -}}
+  {{- else -}}
+    {{- print "networking.gke.io/v1beta1" -}}
+  {{- end -}}
+{{- end -}}
@@ -1,86 +1,22 @@
 {{- /*
-Common deployment spec that is shared between the canary and main Deployment controllers. This template requires the
-context:
+Common deployment spec that is shared between the canary and main Deployment controllers, as well as the StatefulSet
+and DaemonSet controllers that replace the main Deployment depending on `workloadType`. The Pod spec is rendered by
+`k8s-service.podSpec`. This template requires the context:
 - Values
 - Release
 - Chart
+- Files
 - isCanary (a boolean indicating if we are rendering the canary deployment or not)
+- color (optional, the color of the Deployment when rendering the blue and green Deployments)
 You can construct this context using dict:
-(dict "Values" .Values "Release" .Release "Chart" .Chart "isCanary" true)
+(dict "Values" .Values "Release" .Release "Chart" .Chart "Files" .Files "isCanary" true)
 */ -}}
 {{- define "k8s-service.deploymentSpec" -}}
-{{- /*
-We must decide whether or not there are volumes to inject. The logic to decide whether or not to inject is based on
-whether or not there are configMaps OR secrets that are specified as volume mounts (`as: volume` attributes). We do this
-by using a map to track whether or not we have seen a volume type. We have to use a map because we can't update a
-variable in helm chart templates.
-
-Similarly, we need to decide whether or not there are environment variables to add
-
-We need this because certain sections are omitted if there are no volumes or environment variables to add.
-*/ -}}
-
-{{/* Go Templates do not support variable updating, so we simulate it using dictionaries */}}
-{{- $hasInjectionTypes := dict "hasVolume" false "hasEnvVars" false "exposePorts" false -}}
-{{- if .Values.envVars -}}
-  {{- $_ := set $hasInjectionTypes "hasEnvVars" true -}}
-{{- end -}}
-{{- if .Values.additionalContainerEnv -}}
-  {{- $_ := set $hasInjectionTypes "hasEnvVars" true -}}
-{{- end -}}
-{{- $allContainerPorts := values .Values.containerPorts -}}
-{{- range $allContainerPorts -}}
-  {{/* We are exposing ports if there is at least one key in containerPorts that is not disabled (disabled = false or
-       omitted)
-  */}}
-  {{- if or (not (hasKey . "disabled")) (not .disabled) -}}
-    {{- $_ := set $hasInjectionTypes "exposePorts" true -}}
-  {{- end -}}
-{{- end -}}
-{{- $allSecrets := values .Values.secrets -}}
-{{- range $allSecrets -}}
-  {{- if eq (index . "as") "volume" -}}
-    {{- $_ := set $hasInjectionTypes "hasVolume" true -}}
-  {{- else if eq (index . "as") "environment" -}}
-    {{- $_ := set $hasInjectionTypes "hasEnvVars" true -}}
-  {{- else if eq (index . "as") "csi" -}}
-    {{- $_ := set $hasInjectionTypes "hasEnvVars" true -}}
-    {{- $_ := set $hasInjectionTypes "hasVolume" true -}}
-  {{- else if eq (index . "as") "envFrom" }}
-    {{- $_ := set $hasInjectionTypes "hasEnvFrom" true -}}
-  {{- else if eq (index . "as") "none" -}}
-    {{- /* noop */ -}}
-  {{- else -}}
-    {{- fail printf "secrets config has unknown type: %s" (index . "as") -}}
-  {{- end -}}
-{{- end -}}
-{{- $allConfigMaps := values .Values.configMaps -}}
-{{- range $allConfigMaps -}}
-  {{- if eq (index . "as") "volume" -}}
-    {{- $_ := set $hasInjectionTypes "hasVolume" true -}}
-  {{- else if eq (index . "as") "environment" -}}
-    {{- $_ := set $hasInjectionTypes "hasEnvVars" true -}}
-  {{- else if eq (index . "as") "envFrom" }}
-    {{- $_ := set $hasInjectionTypes "hasEnvFrom" true -}}
-  {{- else if eq (index . "as") "none" -}}
-    {{- /* noop */ -}}
-  {{- else -}}
-    {{- fail printf "configMaps config has unknown type: %s" (index . "as") -}}
-  {{- end -}}
-{{- end -}}
-{{- if gt (len .Values.persistentVolumes) 0 -}}
-  {{- $_ := set $hasInjectionTypes "hasVolume" true -}}
-{{- end -}}
-{{- if gt (len .Values.scratchPaths) 0 -}}
-  {{- $_ := set $hasInjectionTypes "hasVolume" true -}}
-{{- end -}}
-{{- if gt (len .Values.emptyDirs) 0 -}}
-  {{- $_ := set $hasInjectionTypes "hasVolume" true -}}
-{{- end -}}
-apiVersion: apps/v1
-kind: Deployment
+{{- $workloadType := include "k8s-service.workloadType" . -}}
+apiVersion: {{ include "k8s-service.workloadAPIVersion" . }}
+kind: {{ include "k8s-service.workloadKind" . }}
 metadata:
-  name: {{ include "k8s-service.fullname" . }}{{ if .isCanary }}-canary{{ end }}
+  name: {{ include "k8s-service.fullname" . }}{{ if .isCanary }}-canary{{ else if .color }}-{{ .color }}{{ end }}
   labels:
     # These labels are required by helm. You can read more about required labels in the chart best practices guide:
     # https://docs.helm.sh/chart_best_practices/#standard-labels
@@ -98,12 +34,59 @@ metadata:
 spec:
 {{- if .isCanary }}
   replicas: {{ .Values.canary.replicaCount | default 1 }}
+{{- else if and .color (ne .color (include "k8s-service.blueGreen.active" .)) }}
+  {{- /* The Horizontal Pod Autoscaler only manages the active color, so we always set the replicas of the other one. */}}
+  {{- $colorValues := index .Values.blueGreen .color }}
+  replicas: {{ if hasKey $colorValues "replicaCount" }}{{ $colorValues.replicaCount }}{{ else }}{{ .Values.replicaCount }}{{ end }}
 {{ else }}
-{{- if not .Values.horizontalPodAutoscaler.enabled }} 
+{{- if and (ne $workloadType "DaemonSet") (not .Values.horizontalPodAutoscaler.enabled) }}
   replicas: {{ .Values.replicaCount }}
+{{- else if .color }}
+  {{- /*
+  The active color is managed by the Horizontal Pod Autoscaler. Removing the replicas when the color becomes active
+  would reset it to a single replica, so we keep the replicas it is currently running instead (the ones it was scaled to
+  with blueGreen.<color>.replicaCount while inactive, or by the Horizontal Pod Autoscaler since).
+  */}}
+  {{- $current := lookup "apps/v1" "Deployment" .Release.Namespace (printf "%s-%s" (include "k8s-service.fullname" .) .color) }}
+  {{- with dig "spec" "replicas" nil ($current | default dict) }}
+  replicas: {{ . }}
+  {{- end }}
 {{- end }}
 {{- end }}
-{{- if .Values.deploymentStrategy.enabled }}
+{{- if eq $workloadType "StatefulSet" }}
+  serviceName: {{ include "k8s-service.statefulSet.serviceName" . }}
+  {{- with .Values.statefulSet.podManagementPolicy }}
+  podManagementPolicy: {{ . }}
+  {{- end }}
+  {{- with .Values.statefulSet.updateStrategy }}
+  updateStrategy:
+{{ toYaml . | indent 4 }}
+  {{- end }}
+{{- else if eq $workloadType "DaemonSet" }}
+  {{- with .Values.daemonSet.updateStrategy }}
+  updateStrategy:
+{{ toYaml . | indent 4 }}
+  {{- end }}
+{{- else if .Values.rollout.enabled }}
+  {{- /*
+  The Rollout strategy is injected from the input values, adding the Services created by this chart that Argo Rollouts
+  uses to split the traffic between the stable and new Pods, unless they are explicitly set.
+  */}}
+  {{- $fullName := include "k8s-service.fullname" . }}
+  {{- $strategyType := include "k8s-service.rollout.strategyType" . }}
+  {{- $strategy := deepCopy (index .Values.rollout.strategy $strategyType | default dict) }}
+  {{- if eq $strategyType "blueGreen" }}
+    {{- if not .Values.service.enabled }}
+      {{- fail "the blueGreen rollout strategy requires service.enabled" }}
+    {{- end }}
+    {{- $_ := merge $strategy (dict "activeService" $fullName "previewService" (printf "%s-preview" $fullName)) }}
+  {{- else if .Values.service.enabled }}
+    {{- $_ := merge $strategy (dict "stableService" $fullName "canaryService" (printf "%s-canary" $fullName)) }}
+  {{- end }}
+  strategy:
+    {{ $strategyType }}:
+{{ toYaml $strategy | indent 6 }}
+{{- else if .Values.deploymentStrategy.enabled }}
   strategy:
     type: {{ .Values.deploymentStrategy.type }}
 {{- if and (eq .Values.deploymentStrategy.type "RollingUpdate") .Values.deploymentStrategy.rollingUpdate }}
@@ -120,6 +103,32 @@ spec:
       {{- else }}
       gruntwork.io/deployment-type: main
       {{- end }}
+      {{- with .color }}
+      gruntwork.io/deployment-color: {{ . }}
+      {{- end }}
+  {{- if and (eq $workloadType "StatefulSet") (gt (len .Values.statefulSet.volumeClaimTemplates) 0) }}
+  volumeClaimTemplates:
+    {{- range $name, $value := .Values.statefulSet.volumeClaimTemplates }}
+    - metadata:
+        name: {{ $name }}
+        {{- with $value.annotations }}
+        annotations:
+{{ toYaml . | indent 10 }}
+        {{- end }}
+      spec:
+        accessModes:
+{{ toYaml ($value.accessModes | default (list "ReadWriteOnce")) | indent 10 }}
+        {{- if $value.storageClassName }}
+        storageClassName: {{ $value.storageClassName | quote }}
+        {{- end }}
+        {{- if $value.volumeMode }}
+        volumeMode: {{ $value.volumeMode }}
+        {{- end }}
+        resources:
+          requests:
+            storage: {{ required "size is required on statefulSet.volumeClaimTemplates entries" $value.size | quote }}
+    {{- end }}
+  {{- end }}
   template:
     metadata:
       labels:
@@ -130,344 +139,17 @@ spec:
         {{- else }}
         gruntwork.io/deployment-type: main
         {{- end }}
+        {{- with .color }}
+        gruntwork.io/deployment-color: {{ . }}
+        {{- end }}
         {{- range $key, $value := .Values.additionalPodLabels }}
         {{ $key }}: "{{ $value }}"
         {{- end }}
 
-      {{- with .Values.podAnnotations }}
+      {{- with merge (dict) .Values.podAnnotations (include "k8s-service.vaultAnnotations" . | fromYaml) (include "k8s-service.configChecksumAnnotations" . | fromYaml) }}
       annotations:
 {{ toYaml . | indent 8 }}
       {{- end }}
     spec:
-      {{- if gt (len .Values.serviceAccount.name) 0 }}
-      serviceAccountName: "{{ .Values.serviceAccount.name }}"
-      {{- end }}
-      {{- if hasKey .Values.serviceAccount "automountServiceAccountToken" }}
-      automountServiceAccountToken : {{ .Values.serviceAccount.automountServiceAccountToken }}
-      {{- end }}
-      {{- if .Values.podSecurityContext }}
-      securityContext:
-{{ toYaml .Values.podSecurityContext | indent 8 }}
-      {{- end}}
-      {{- if .Values.hostAliases }}
-      hostAliases:
-{{ toYaml .Values.hostAliases | indent 8 }}
-      {{- end }}
-
-      {{- if .Values.dnsPolicy }}
-      dnsPolicy: {{ .Values.dnsPolicy }}
-      {{- end }}
-
-      containers:
-        {{- if .isCanary }}
-        - name: {{ .Values.applicationName }}-canary
-          {{- $repo := required ".Values.canary.containerImage.repository is required" .Values.canary.containerImage.repository }}
-          {{- $tag := required ".Values.canary.containerImage.tag is required" .Values.canary.containerImage.tag }}
-          image: "{{ $repo }}:{{ $tag }}"
-          imagePullPolicy: {{ .Values.canary.containerImage.pullPolicy | default "IfNotPresent" }}
-        {{- else }}
-        - name: {{ .Values.applicationName }}
-          {{- $repo := required ".Values.containerImage.repository is required" .Values.containerImage.repository }}
-          {{- $tag := required ".Values.containerImage.tag is required" .Values.containerImage.tag }}
-          image: "{{ $repo }}:{{ $tag }}"
-          imagePullPolicy: {{ .Values.containerImage.pullPolicy | default "IfNotPresent" }}
-        {{- end }}
-          {{- if .Values.containerCommand }}
-          command:
-{{ toYaml .Values.containerCommand | indent 12 }}
-          {{- end }}
-          {{- if .Values.containerArgs }}
-          args:
-{{ toYaml .Values.containerArgs | indent 12 }}
-          {{- end }}
-          {{- if index $hasInjectionTypes "exposePorts" }}
-          ports:
-            {{- /*
-              NOTE: we check for a disabled flag here so that users of the helm
-              chart can override the default containerPorts. Specifically, defining a new
-              containerPorts in values.yaml will be merged with the default provided by the
-              chart. For example, if the user provides:
-
-                    containerPorts:
-                      app:
-                        port: 8080
-                        protocol: TCP
-
-              Then this is merged with the default and becomes:
-
-                    containerPorts:
-                      app:
-                        port: 8080
-                        protocol: TCP
-                      http:
-                        port: 80
-                        protocol: TCP
-                      https:
-                        port: 443
-                        protocol: TCP
-
-              and so it becomes append as opposed to replace. To handle this,
-              we allow users to explicitly disable predefined ports. So if the user wants to
-              replace the ports with their own, they would provide the following values file:
-
-                    containerPorts:
-                      app:
-                        port: 8080
-                        protocol: TCP
-                      http:
-                        disabled: true
-                      https:
-                        disabled: true
-            */ -}}
-            {{- range $key, $portSpec := .Values.containerPorts }}
-            {{- if not $portSpec.disabled }}
-            - name: {{ $key }}
-              containerPort: {{ int $portSpec.port }}
-              protocol: {{ $portSpec.protocol }}
-            {{- end }}
-            {{- end }}
-          {{- end }}
-
-          {{- if .Values.startupProbe }}
-          startupProbe:
-{{ toYaml .Values.startupProbe | indent 12 }}
-          {{- end }}
-
-          {{- if .Values.livenessProbe }}
-          livenessProbe:
-{{ toYaml .Values.livenessProbe | indent 12 }}
-          {{- end }}
-
-          {{- if .Values.readinessProbe }}
-          readinessProbe:
-{{ toYaml .Values.readinessProbe | indent 12 }}
-          {{- end }}
-          {{- if .Values.securityContext }}
-          securityContext:
-{{ toYaml .Values.securityContext | indent 12 }}
-          {{- end}}
-          resources:
-{{ toYaml .Values.containerResources | indent 12 }}
-
-          {{- if or .Values.lifecycleHooks.enabled (gt (int .Values.shutdownDelay) 0) }}
-          lifecycle:
-            {{- if and .Values.lifecycleHooks.enabled .Values.lifecycleHooks.postStart }}
-            postStart:
-{{ toYaml .Values.lifecycleHooks.postStart | indent 14 }}
-            {{- end }}
-
-            {{- if and .Values.lifecycleHooks.enabled .Values.lifecycleHooks.preStop }}
-            preStop:
-{{ toYaml .Values.lifecycleHooks.preStop | indent 14 }}
-            {{- else if gt (int .Values.shutdownDelay) 0 }}
-            # Include a preStop hook with a shutdown delay for eventual consistency reasons.
-            # See https://blog.gruntwork.io/delaying-shutdown-to-wait-for-pod-deletion-propagation-445f779a8304
-            preStop:
-              exec:
-                command:
-                  - sleep
-                  - "{{ int .Values.shutdownDelay }}"
-            {{- end }}
-
-          {{- end }}
-
-          {{- /* START ENV VAR LOGIC */ -}}
-          {{- if index $hasInjectionTypes "hasEnvVars" }}
-          env:
-          {{- end }}
-          {{- range $key, $value := .Values.envVars }}
-            - name: {{ $key }}
-              value: {{ quote $value }}
-          {{- end }}
-          {{- if .Values.additionalContainerEnv }}
-{{ toYaml .Values.additionalContainerEnv | indent 12 }}
-          {{- end }}
-          {{- range $name, $value := .Values.configMaps }}
-            {{- if eq $value.as "environment" }}
-            {{- range $configKey, $keyEnvVarConfig := $value.items }}
-            - name: {{ required "envVarName is required on configMaps items when using environment" $keyEnvVarConfig.envVarName | quote }}
-              valueFrom:
-                configMapKeyRef:
-                  name: {{ $name }}
-                  key: {{ $configKey }}
-            {{- end }}
-            {{- end }}
-          {{- end }}
-          {{- range $name, $value := .Values.secrets }}
-            {{- if or (eq $value.as "environment") (eq $value.as "csi") }}
-            {{- range $secretKey, $keyEnvVarConfig := $value.items }}
-            - name: {{ required "envVarName is required on secrets items when using environment or csi" $keyEnvVarConfig.envVarName | quote }}
-              valueFrom:
-                secretKeyRef:
-                  name: {{ $name }}
-                  key: {{ $secretKey }}
-            {{- end }}
-            {{- end }}
-          {{- end }}
-          {{- if index $hasInjectionTypes "hasEnvFrom" }}
-          envFrom:
-          {{- range $name, $value := .Values.configMaps }}
-            {{- if eq $value.as "envFrom" }}
-            - configMapRef:
-                name: {{ $name }}
-            {{- end }}
-          {{- end }}
-          {{- range $name, $value := .Values.secrets }}
-            {{- if eq $value.as "envFrom" }}
-            - secretRef:
-                name: {{ $name }}
-            {{- end }}
-          {{- end }}
-          {{- end }}
-          {{- /* END ENV VAR LOGIC */ -}}
-
-
-          {{- /* START VOLUME MOUNT LOGIC */ -}}
-          {{- if index $hasInjectionTypes "hasVolume" }}
-          volumeMounts:
-          {{- end }}
-          {{- range $name, $value := .Values.configMaps }}
-            {{- if eq $value.as "volume" }}
-            - name: {{ $name }}-volume
-              mountPath: {{ quote $value.mountPath }}
-              {{- if $value.subPath }}
-              subPath: {{ quote $value.subPath }}
-              {{- end }}
-            {{- end }}
-          {{- end }}
-          {{- range $name, $value := .Values.secrets }}
-            {{- if or (eq $value.as "volume") (eq $value.as "csi") }}
-            - name: {{ $name }}-volume
-              mountPath: {{ quote $value.mountPath }}
-              {{- if $value.subPath }}
-              subPath: {{ quote $value.subPath }}
-              {{- end }}
-              readOnly: {{ $value.readOnly }}
-            {{- end }}
-          {{- end }}
-          {{- range $name, $value := .Values.persistentVolumes }}
-            - name: {{ $name }}
-              mountPath: {{ quote $value.mountPath }}
-          {{- end }}
-          {{- range $name, $value := .Values.scratchPaths }}
-            - name: {{ $name }}
-              mountPath: {{ quote $value }}
-          {{- end }}
-          {{- range $name, $value := .Values.emptyDirs }}
-            - name: {{ $name }}
-              mountPath: {{ quote $value }}
-          {{- end }}
-          {{- /* END VOLUME MOUNT LOGIC */ -}}
-
-        {{- range $key, $value := .Values.sideCarContainers }}
-        - name: {{ $key }}
-{{ toYaml $value | indent 10 }}
-        {{- end }}
-
-
-    {{- if gt (len .Values.initContainers) 0 }}
-      initContainers:
-        {{- range $key, $value := .Values.initContainers }}
-        - name: {{ $key }}
-{{ toYaml $value | indent 10 }}
-        {{- end }}
-    {{- end }}
-
-    {{- /* START IMAGE PULL SECRETS LOGIC */ -}}
-    {{- if gt (len .Values.imagePullSecrets) 0 }}
-      imagePullSecrets:
-        {{- range $secretName := .Values.imagePullSecrets }}
-        - name: {{ $secretName }}
-        {{- end }}
-    {{- end }}
-    {{- /* END IMAGE PULL SECRETS LOGIC */ -}}
-
-    {{- /* START TERMINATION GRACE PERIOD LOGIC */ -}}
-    {{- if .Values.terminationGracePeriodSeconds }}
-      terminationGracePeriodSeconds: {{ .Values.terminationGracePeriodSeconds }}
-    {{- end}}
-    {{- /* END TERMINATION GRACE PERIOD LOGIC */ -}}
-
-    {{- /* START VOLUME LOGIC */ -}}
-    {{- if index $hasInjectionTypes "hasVolume" }}
-      volumes:
-    {{- end }}
-    {{- range $name, $value := .Values.configMaps }}
-      {{- if eq $value.as "volume" }}
-        - name: {{ $name }}-volume
-          configMap:
-            name: {{ $name }}
-            {{- if $value.items }}
-            items:
-              {{- range $configKey, $keyMountConfig := $value.items }}
-              - key: {{ $configKey }}
-                path: {{ required "filePath is required for configMap items" $keyMountConfig.filePath | quote }}
-                {{- if $keyMountConfig.fileMode }}
-                mode: {{ include "k8s-service.fileModeOctalToDecimal" $keyMountConfig.fileMode }}
-                {{- end }}
-              {{- end }}
-            {{- end }}
-      {{- end }}
-    {{- end }}
-    {{- range $name, $value := .Values.secrets }}
-      {{- if eq $value.as "volume" }}
-        - name: {{ $name }}-volume
-          secret:
-            secretName: {{ $name }}
-            {{- if $value.items }}
-            items:
-              {{- range $secretKey, $keyMountConfig := $value.items }}
-              - key: {{ $secretKey }}
-                path: {{ required "filePath is required for secrets items" $keyMountConfig.filePath | quote }}
-                {{- if $keyMountConfig.fileMode }}
-                mode: {{ include "k8s-service.fileModeOctalToDecimal" $keyMountConfig.fileMode }}
-                {{- end }}
-              {{- end }}
-            {{- end }}
-      {{- end }}
-      {{- if eq $value.as "csi" }}
-        - name: {{ $name }}-volume
-          csi: 
-            readOnly: {{ $value.readOnly }}
-            driver:  {{ $value.csi.driver }}
-            volumeAttributes:
-              secretProviderClass: {{ $value.csi.secretProviderClass }}
-
-      {{- end }}    
-    {{- end }}
-    {{- range $name, $value := .Values.persistentVolumes }}
-        - name: {{ $name }}
-          persistentVolumeClaim:
-            claimName: {{ $value.claimName }}
-    {{- end }}
-    {{- range $name, $value := .Values.scratchPaths }}
-        - name: {{ $name }}
-          emptyDir:
-            medium: "Memory"
-    {{- end }}
-    {{- range $name, $value := .Values.emptyDirs }}
-        - name: {{ $name }}
-          emptyDir: {}
-    {{- end }}
-    {{- /* END VOLUME LOGIC */ -}}
-
-    {{- with .Values.nodeSelector }}
-      nodeSelector:
-{{ toYaml . | indent 8 }}
-    {{- end }}
-
-    {{- with .Values.affinity }}
-      affinity:
-{{ toYaml . | indent 8 }}
-    {{- end }}
-
-    {{- with .Values.priorityClassName }}
-      priorityClassName:
-{{ toYaml . | indent 8 }}
-    {{- end }}
-
-    {{- with .Values.tolerations }}
-      tolerations:
-{{ toYaml . | indent 8 }}
-    {{- end }}
+{{- include "k8s-service.podSpec" . }}
 {{- end -}}
@@ -30,6 +30,488 @@ Create chart name and version as used by the chart label.
   {{- printf "%s-%s" .Chart.Name .Chart.Version | replace "+" "_" | trunc 63 | trimSuffix "-" -}}
 {{- end -}}
 
+{{/*
+Return the kind of the controller that manages the application Pods, validating that it is one of the supported
+workload types.
+*/}}
+{{- define "k8s-service.workloadType" -}}
+  {{- $workloadType := .Values.workloadType | default "Deployment" -}}
+  {{- if not (has $workloadType (list "Deployment" "StatefulSet" "DaemonSet")) -}}
+    {{- fail (printf "workloadType has unknown value: %s" $workloadType) -}}
+  {{- end -}}
+  {{- $workloadType -}}
+{{- end -}}
+
+{{/*
+Return the kind of the resource that manages the main application Pods. This is the workload type, unless Argo Rollouts
+is enabled, in which case the Pods are managed by a Rollout in place of the main Deployment.
+*/}}
+{{- define "k8s-service.workloadKind" -}}
+  {{- if .Values.rollout.enabled -}}
+    {{- if ne (include "k8s-service.workloadType" .) "Deployment" -}}
+      {{- fail "rollout is only supported when workloadType is Deployment" -}}
+    {{- end -}}
+    {{- if .Values.blueGreen.enabled -}}
+      {{- fail "rollout can not be used together with blueGreen" -}}
+    {{- end -}}
+    {{- print "Rollout" -}}
+  {{- else -}}
+    {{- include "k8s-service.workloadType" . -}}
+  {{- end -}}
+{{- end -}}
+
+{{/*
+Return the API version of the resource that manages the main application Pods.
+*/}}
+{{- define "k8s-service.workloadAPIVersion" -}}
+  {{- if eq (include "k8s-service.workloadKind" .) "Rollout" -}}
+    {{- print "argoproj.io/v1alpha1" -}}
+  {{- else -}}
+    {{- print "apps/v1" -}}
+  {{- end -}}
+{{- end -}}
+
+{{/*
+The strategy (canary or blueGreen) of the Rollout when Argo Rollouts is enabled, and an empty string otherwise. The
+strategy is determined by which of the keys is set on `rollout.strategy`, and defaults to canary when neither is set.
+*/}}
+{{- define "k8s-service.rollout.strategyType" -}}
+  {{- if .Values.rollout.enabled -}}
+    {{- $strategy := .Values.rollout.strategy | default dict -}}
+    {{- if and (hasKey $strategy "canary") (hasKey $strategy "blueGreen") -}}
+      {{- fail "rollout.strategy must set at most one of canary or blueGreen" -}}
+    {{- else if hasKey $strategy "blueGreen" -}}
+      {{- print "blueGreen" -}}
+    {{- else -}}
+      {{- print "canary" -}}
+    {{- end -}}
+  {{- end -}}
+{{- end -}}
+
+{{/*
+Name of the governing Service of the StatefulSet. Defaults to the headless Service created by this chart. We truncate
+the fullname to leave room for the suffix within the 63 character limit.
+*/}}
+{{- define "k8s-service.statefulSet.serviceName" -}}
+  {{- if .Values.statefulSet.serviceName -}}
+    {{- .Values.statefulSet.serviceName -}}
+  {{- else -}}
+    {{- printf "%s-headless" (include "k8s-service.fullname" . | trunc 54 | trimSuffix "-") -}}
+  {{- end -}}
+{{- end -}}
+
+{{/*
+Whether or not the traffic to the canary Pods is split by weight, using a dedicated canary Service and Ingress, instead
+of by the ratio of the canary and main replicas. Renders "true" when the canary is enabled and `canary.trafficWeight` is
+set, and an empty string otherwise. This fails when the Ingress is disabled, since the main Service is restricted to the
+main Pods and only the canary Ingress routes traffic to the canary Pods.
+*/}}
+{{- define "k8s-service.canary.trafficWeightEnabled" -}}
+  {{- if and .Values.canary.enabled (not (kindIs "invalid" .Values.canary.trafficWeight)) -}}
+    {{- if or (lt (int .Values.canary.trafficWeight) 0) (gt (int .Values.canary.trafficWeight) 100) -}}
+      {{- fail "canary.trafficWeight must be a percentage between 0 and 100" -}}
+    {{- end -}}
+    {{- if not .Values.ingress.enabled -}}
+      {{- fail "canary.trafficWeight requires ingress.enabled, as the traffic is routed to the canary by the canary Ingress" -}}
+    {{- end -}}
+    {{- print "true" -}}
+  {{- end -}}
+{{- end -}}
+
+{{/*
+The color (blue or green) of the Deployment that receives the traffic of the Service when blue/green deployments are
+enabled, and an empty string otherwise.
+*/}}
+{{- define "k8s-service.blueGreen.active" -}}
+  {{- if .Values.blueGreen.enabled -}}
+    {{- if ne (include "k8s-service.workloadType" .) "Deployment" -}}
+      {{- fail "blueGreen is only supported when workloadType is Deployment" -}}
+    {{- end -}}
+    {{- if not (has .Values.blueGreen.active (list "blue" "green")) -}}
+      {{- fail (printf "blueGreen.active has unknown value: %s" .Values.blueGreen.active) -}}
+    {{- end -}}
+    {{- .Values.blueGreen.active -}}
+  {{- end -}}
+{{- end -}}
+
+{{/*
+The color (blue or green) of the Deployment that is targeted by the preview Service when blue/green deployments are
+enabled, and an empty string otherwise.
+*/}}
+{{- define "k8s-service.blueGreen.preview" -}}
+  {{- $active := include "k8s-service.blueGreen.active" . -}}
+  {{- if eq $active "blue" -}}
+    {{- print "green" -}}
+  {{- else if eq $active "green" -}}
+    {{- print "blue" -}}
+  {{- end -}}
+{{- end -}}
+
+{{/*
+Name of the controller that manages the main application Pods, as targeted by the autoscalers. When blue/green
+deployments are enabled, this is the Deployment of the active color.
+*/}}
+{{- define "k8s-service.workloadName" -}}
+  {{- $active := include "k8s-service.blueGreen.active" . -}}
+  {{- if $active -}}
+    {{- printf "%s-%s" (include "k8s-service.fullname" .) $active -}}
+  {{- else -}}
+    {{- include "k8s-service.fullname" . -}}
+  {{- end -}}
+{{- end -}}
+
+{{/*
+Name of the Job (or CronJob) resource rendered for an entry of the `jobs` input value. Expects a dict with the chart
+context under `context` and the key of the job under `jobName`. CronJob names are limited to 52 characters because the
+CronJob controller appends a suffix to the name when creating Jobs, so we always truncate to that length.
+*/}}
+{{- define "k8s-service.jobName" -}}
+  {{- printf "%s-%s" (include "k8s-service.fullname" .context) .jobName | trunc 52 | trimSuffix "-" -}}
+{{- end -}}
+
+{{/*
+Name of the PersistentVolumeClaim of an entry of the `persistentVolumes` input value. When the chart creates the claim
+(the size is set), this defaults to the fullname of the release suffixed with the key of the entry, otherwise the
+claimName of the existing claim is required. Expects the root context under `context`, the key of the entry under `name`
+and the entry under `volume`.
+*/}}
+{{- define "k8s-service.persistentVolume.claimName" -}}
+  {{- if .volume.size -}}
+    {{- .volume.claimName | default (printf "%s-%s" (include "k8s-service.fullname" .context) .name | trunc 63 | trimSuffix "-") -}}
+  {{- else -}}
+    {{- required (printf "persistentVolumes.%s.claimName is required when size is not set" .name) .volume.claimName -}}
+  {{- end -}}
+{{- end -}}
+
+{{/*
+The reason the application may run multiple Pods at the same time, or an empty string when it always runs a single Pod.
+This is used to reject the ReadWriteOnce claims created by the chart, which can only be mounted from a single node.
+*/}}
+{{- define "k8s-service.multiplePodsReason" -}}
+  {{- if eq (include "k8s-service.workloadType" .) "DaemonSet" -}}
+    {{- print "workloadType DaemonSet" -}}
+  {{- else if gt (int .Values.replicaCount) 1 -}}
+    {{- print "replicaCount > 1" -}}
+  {{- else if and .Values.horizontalPodAutoscaler.enabled (gt (int .Values.horizontalPodAutoscaler.maxReplicas) 1) -}}
+    {{- print "horizontalPodAutoscaler.maxReplicas > 1" -}}
+  {{- else if .Values.canary.enabled -}}
+    {{- print "canary" -}}
+  {{- else if .Values.blueGreen.enabled -}}
+    {{- print "blueGreen" -}}
+  {{- else if .Values.rollout.enabled -}}
+    {{- print "rollout" -}}
+  {{- else if .Values.flagger.enabled -}}
+    {{- print "flagger" -}}
+  {{- end -}}
+{{- end -}}
+
+{{/*
+The annotations that render a resource the migrations Job may depend on (the ConfigMaps, Secrets, PersistentVolumeClaims,
+image pull Secret and ServiceAccount managed by the chart) as a pre-install hook that runs before the migrations, or an
+empty string when there is no need to. The migrations Job runs as a pre-install hook, before the other resources of the
+chart are created on the first install. On upgrades the resources already exist, so they are rendered as regular
+resources, and the release ownership annotations allow Helm to adopt the resources created by the hook.
+*/}}
+{{- define "k8s-service.migrations.dependencyHookAnnotations" -}}
+  {{- if and .Values.migrations.enabled .Release.IsInstall -}}
+"helm.sh/hook": pre-install
+"helm.sh/hook-weight": {{ sub (int .Values.migrations.hookWeight) 1 | quote }}
+meta.helm.sh/release-name: {{ .Release.Name }}
+meta.helm.sh/release-namespace: {{ .Release.Namespace }}
+  {{- end -}}
+{{- end -}}
+
+{{/*
+The rules of an Ingress, as a json list of `host` and ordered `paths` entries. When the `rules` of the ingress config are
+set they are used as is, otherwise every entry of `hosts` (or all hosts when empty) gets the additionalPathsHigherPriority,
+the application service path and the additionalPaths, in that order. Expects the ingress config as the context.
+*/}}
+{{- define "k8s-service.ingress.rules" -}}
+  {{- $rules := .rules | default list -}}
+  {{- if not $rules -}}
+    {{- $paths := concat (.additionalPathsHigherPriority | default list) (list (dict "path" .path "pathType" .pathType "servicePort" .servicePort)) (.additionalPaths | default list) -}}
+    {{- range (.hosts | default (list "")) -}}
+      {{- $rules = append $rules (dict "host" . "paths" $paths) -}}
+    {{- end -}}
+  {{- end -}}
+  {{- toJson $rules -}}
+{{- end -}}
+
+{{/*
+The hosts routed by an Ingress, as a json list. These are the hosts of the rules when set, otherwise the hosts of the
+ingress config. Expects the ingress config as the context.
+*/}}
+{{- define "k8s-service.ingress.hosts" -}}
+  {{- $hosts := list -}}
+  {{- if .rules -}}
+    {{- range .rules -}}
+      {{- if .host -}}
+        {{- $hosts = append $hosts .host -}}
+      {{- end -}}
+    {{- end -}}
+  {{- else -}}
+    {{- $hosts = .hosts | default list -}}
+  {{- end -}}
+  {{- toJson (uniq $hosts) -}}
+{{- end -}}
+
+{{/*
+The TLS configuration of an Ingress, as a json list. When cert-manager is enabled for the Ingress and the tls of the
+ingress config is omitted, a single TLS entry covering all the hosts of the Ingress is generated, using a Secret named
+after the Ingress. Expects the name of the Ingress under `ingressName` and the ingress config under `ingress`.
+*/}}
+{{- define "k8s-service.ingress.tls" -}}
+  {{- $tls := .ingress.tls | default list -}}
+  {{- if and (not $tls) (dig "certManager" "enabled" false .ingress) -}}
+    {{- $hosts := include "k8s-service.ingress.hosts" .ingress | fromJsonArray -}}
+    {{- if not $hosts -}}
+      {{- fail (printf "Ingress %s must route at least one host to generate the TLS configuration with certManager" .ingressName) -}}
+    {{- end -}}
+    {{- $tls = list (dict "hosts" $hosts "secretName" (printf "%s-tls" .ingressName)) -}}
+  {{- end -}}
+  {{- toJson $tls -}}
+{{- end -}}
+
+{{/*
+The issuerRef of the cert-manager certificates of an Ingress, rendered as yaml. Exactly one of issuer and clusterIssuer
+must be set. Expects the certManager config of the ingress as the context.
+*/}}
+{{- define "k8s-service.ingress.certManager.issuerRef" -}}
+  {{- if eq (empty .issuer) (empty .clusterIssuer) -}}
+    {{- fail "certManager must set exactly one of issuer or clusterIssuer" -}}
+  {{- end -}}
+  {{- toYaml (dict "name" (.issuer | default .clusterIssuer) "kind" (ternary "Issuer" "ClusterIssuer" (not (empty .issuer))) "group" "cert-manager.io") -}}
+{{- end -}}
+
+{{/*
+The cert-manager annotations of an Ingress, rendered as yaml. These are only set when cert-manager is enabled for the
+Ingress without rendering the Certificate resources, in which case the certificates are created by the ingress-shim of
+cert-manager. Expects the ingress config as the context.
+*/}}
+{{- define "k8s-service.ingress.certManager.annotations" -}}
+  {{- $certManager := .certManager | default dict -}}
+  {{- if and $certManager.enabled (not $certManager.createCertificate) -}}
+    {{- $issuerRef := include "k8s-service.ingress.certManager.issuerRef" $certManager | fromYaml -}}
+    {{- $annotations := dict (ternary "cert-manager.io/issuer" "cert-manager.io/cluster-issuer" (eq $issuerRef.kind "Issuer")) $issuerRef.name -}}
+    {{- with $certManager.duration -}}
+      {{- $_ := set $annotations "cert-manager.io/duration" . -}}
+    {{- end -}}
+    {{- with $certManager.renewBefore -}}
+      {{- $_ := set $annotations "cert-manager.io/renew-before" . -}}
+    {{- end -}}
+    {{- toYaml $annotations -}}
+  {{- end -}}
+{{- end -}}
+
+{{/*
+The URLs exposed by an Ingress, as a json list. These are the application service path of each host, or every path of the
+rules that set a host. Expects the ingress config as the context.
+*/}}
+{{- define "k8s-service.ingress.urls" -}}
+  {{- $scheme := ternary "https" "http" (or (not (empty .tls)) (dig "certManager" "enabled" false .)) -}}
+  {{- $urls := list -}}
+  {{- if .rules -}}
+    {{- range .rules -}}
+      {{- $host := .host -}}
+      {{- range (ternary .paths list (not (empty $host))) -}}
+        {{- $urls = append $urls (printf "%s://%s%s" $scheme $host .path) -}}
+      {{- end -}}
+    {{- end -}}
+  {{- else -}}
+    {{- $path := .path -}}
+    {{- range .hosts -}}
+      {{- $urls = append $urls (printf "%s://%s%s" $scheme . $path) -}}
+    {{- end -}}
+  {{- end -}}
+  {{- toJson $urls -}}
+{{- end -}}
+
+{{/*
+The name of the GKE BackendConfig resource of the release.
+*/}}
+{{- define "k8s-service.google.backendConfigName" -}}
+  {{- .Values.google.backendConfig.name | default (include "k8s-service.fullname" .) -}}
+{{- end -}}
+
+{{/*
+The name of the GKE FrontendConfig resource of the release.
+*/}}
+{{- define "k8s-service.google.frontendConfigName" -}}
+  {{- .Values.google.frontendConfig.name | default (include "k8s-service.fullname" .) -}}
+{{- end -}}
+
+{{/*
+The annotations associating the Service with the GKE BackendConfig of the release, rendered as yaml.
+*/}}
+{{- define "k8s-service.google.serviceAnnotations" -}}
+  {{- if .Values.google.backendConfig.enabled -}}
+    {{- toYaml (dict "cloud.google.com/backend-config" (toJson (dict "default" (include "k8s-service.google.backendConfigName" .)))) -}}
+  {{- end -}}
+{{- end -}}
+
+{{/*
+The annotations associating the Ingress with the GKE FrontendConfig of the release, rendered as yaml.
+*/}}
+{{- define "k8s-service.google.ingressAnnotations" -}}
+  {{- if .Values.google.frontendConfig.enabled -}}
+    {{- toYaml (dict "networking.gke.io/v1beta1.FrontendConfig" (include "k8s-service.google.frontendConfigName" .)) -}}
+  {{- end -}}
+{{- end -}}
+
+{{/*
+The port number of a backend of the Gateway API routes. Route backends can only refer to port numbers, so a named port is
+resolved to the number of the port with the same name in `service.ports`, which is only possible when routing to the
+Service of the release. Expects the port under `servicePort`, the optional `serviceName` of the backend and the Values
+under `Values`.
+*/}}
+{{- define "k8s-service.gateway.servicePort" -}}
+  {{- $servicePort := toString .servicePort -}}
+  {{- if regexMatch "^[0-9]+$" $servicePort -}}
+    {{- print $servicePort -}}
+  {{- else if .serviceName -}}
+    {{- fail (printf "servicePort %s must be a port number when routing the gateway to the Service %s" $servicePort .serviceName) -}}
+  {{- else -}}
+    {{- $port := index (.Values.service.ports | default dict) $servicePort | default dict -}}
+    {{- print (required (printf "servicePort %s of the gateway must be a port number or the name of a port in service.ports" $servicePort) $port.port) -}}
+  {{- end -}}
+{{- end -}}
+
+{{/*
+A rule of the HTTPRoute rendered by gateway.yaml, rendered as yaml. The rule matches the `path` (and the optional
+`headers`) and routes to the `servicePort` of the `serviceName` Service, which defaults to the Service of the release.
+The optional `filters` and additional weighted `backendRefs` are added to the rule as is. Expects the path entry under
+`path`, the fullname of the release under `fullName` and the Values under `Values`.
+*/}}
+{{- define "k8s-service.gateway.httpRouteRule" -}}
+  {{- $match := dict "path" (dict "type" (.path.pathType | default "PathPrefix") "value" (required "path is required on gateway paths" .path.path)) -}}
+  {{- with .path.headers -}}
+    {{- $_ := set $match "headers" . -}}
+  {{- end -}}
+  {{- $port := include "k8s-service.gateway.servicePort" (dict "servicePort" (required "servicePort is required on gateway paths" .path.servicePort) "serviceName" .path.serviceName "Values" .Values) -}}
+  {{- $backendRef := dict "name" (.path.serviceName | default .fullName) "port" (int $port) -}}
+  {{- if hasKey .path "weight" -}}
+    {{- $_ := set $backendRef "weight" (int .path.weight) -}}
+  {{- end -}}
+  {{- $rule := dict "matches" (list $match) "backendRefs" (prepend (.path.backendRefs | default list) $backendRef) -}}
+  {{- with .path.filters -}}
+    {{- $_ := set $rule "filters" . -}}
+  {{- end -}}
+  {{- toYaml $rule -}}
+{{- end -}}
+
+{{/*
+Peers of a NetworkPolicy rule built from the `namespaceLabels`, `podLabels` and `cidrs` allow-lists of the
+`networkPolicy.ingress` or `networkPolicy.egress` input values. Renders nothing if none of the allow-lists are set.
+NOTE: The leading whitespace is significant, as it is the specific yaml indentation for injection into the rule.
+*/}}
+{{- define "k8s-service.networkPolicy.peers" -}}
+        {{- range .namespaceLabels }}
+        - namespaceSelector:
+            matchLabels:
+{{ toYaml . | indent 14 }}
+        {{- end }}
+        {{- range .podLabels }}
+        - podSelector:
+            matchLabels:
+{{ toYaml . | indent 14 }}
+        {{- end }}
+        {{- range .cidrs }}
+        - ipBlock:
+            cidr: {{ . }}
+        {{- end }}
+{{- end -}}
+
+{{/*
+The data of a ConfigMap that is created by the chart, rendered as yaml. This combines the inline `data` with the
+contents of the chart files matching the `files` glob patterns, keyed by the base name of each file. Expects the
+configMaps entry under `config` and the chart files under `Files`.
+*/}}
+{{- define "k8s-service.configMap.data" -}}
+  {{- $data := dict -}}
+  {{- range $key, $value := .config.data -}}
+    {{- $_ := set $data $key (toString $value) -}}
+  {{- end -}}
+  {{- range $pattern := .config.files -}}
+    {{- range $path, $_ := $.Files.Glob $pattern -}}
+      {{- $_ := set $data (base $path) ($.Files.Get $path) -}}
+    {{- end -}}
+  {{- end -}}
+  {{- if $data -}}
+    {{- toYaml $data -}}
+  {{- end -}}
+{{- end -}}
+
+{{/*
+Pod annotations holding the sha256 checksum of each ConfigMap and Secret that is created by the chart, rendered as
+yaml. When `configChecksumAnnotations` is enabled, changing the contents of a ConfigMap or Secret changes the Pod
+template, which triggers a rollout of the Pods so that they pick up the new configuration. Secrets in the keepExisting
+mode are skipped, as their contents are not managed by the chart once they exist.
+*/}}
+{{- define "k8s-service.configChecksumAnnotations" -}}
+  {{- if .Values.configChecksumAnnotations -}}
+    {{- range $name, $config := .Values.configMaps -}}
+      {{- if or $config.data $config.binaryData $config.files }}
+{{ printf "checksum/configmap-%s" $name | trunc 72 | trimSuffix "-" }}: {{ printf "%s\n%s" (include "k8s-service.configMap.data" (dict "config" $config "Files" $.Files)) (toYaml ($config.binaryData | default dict)) | sha256sum | quote }}
+      {{- end -}}
+    {{- end -}}
+    {{- range $name, $secret := .Values.secrets -}}
+      {{- if and (or $secret.data $secret.stringData) (not $secret.keepExisting) }}
+{{ printf "checksum/secret-%s" $name | trunc 72 | trimSuffix "-" }}: {{ printf "%s\n%s" (toYaml ($secret.data | default dict)) (toYaml ($secret.stringData | default dict)) | sha256sum | quote }}
+      {{- end -}}
+    {{- end -}}
+  {{- end -}}
+{{- end -}}
+
+{{/*
+Pod annotations for the HashiCorp Vault Agent injector, rendered as yaml from the `vault` input value. Each entry of
+`vault.secrets` is rendered by the agent to the file `/vault/secrets/<name>`, using the optional template.
+*/}}
+{{- define "k8s-service.vaultAnnotations" -}}
+  {{- if .Values.vault.enabled -}}
+    {{- if not .Values.vault.secrets -}}
+      {{- fail "vault.secrets must contain at least one secret when vault is enabled" -}}
+    {{- end }}
+vault.hashicorp.com/agent-inject: "true"
+vault.hashicorp.com/role: {{ required "vault.role is required when vault is enabled" .Values.vault.role | quote }}
+    {{- with .Values.vault.authPath }}
+vault.hashicorp.com/auth-path: {{ . | quote }}
+    {{- end }}
+    {{- if .Values.vault.initFirst }}
+vault.hashicorp.com/agent-init-first: "true"
+    {{- end }}
+    {{- if .Values.vault.prePopulateOnly }}
+vault.hashicorp.com/agent-pre-populate-only: "true"
+    {{- end }}
+    {{- range $name, $secret := .Values.vault.secrets }}
+vault.hashicorp.com/agent-inject-secret-{{ $name }}: {{ required (printf "vault.secrets.%s.path is required" $name) $secret.path | quote }}
+      {{- with $secret.template }}
+vault.hashicorp.com/agent-inject-template-{{ $name }}: {{ . | quote }}
+      {{- end }}
+    {{- end }}
+  {{- end -}}
+{{- end -}}
+
+{{/*
+Name of the image pull Secret that is created by the chart from the `imageCredentials` input value. Defaults to the
+fullname of the release suffixed with `-registry`.
+*/}}
+{{- define "k8s-service.imageCredentials.secretName" -}}
+  {{- .Values.imageCredentials.name | default (printf "%s-registry" (include "k8s-service.fullname" .) | trunc 63 | trimSuffix "-") -}}
+{{- end -}}
+
+{{/*
+The names of the Secrets used to pull the container images, rendered as a JSON list. This combines the
+`imagePullSecrets` input value with the image pull Secret created by the chart when `imageCredentials` is enabled, and
+is shared between the Pod spec and the ServiceAccount.
+*/}}
+{{- define "k8s-service.imagePullSecrets" -}}
+  {{- $imagePullSecrets := .Values.imagePullSecrets | default list -}}
+  {{- if .Values.imageCredentials.enabled -}}
+    {{- $imagePullSecrets = append $imagePullSecrets (include "k8s-service.imageCredentials.secretName" .) -}}
+  {{- end -}}
+  {{- toJson $imagePullSecrets -}}
+{{- end -}}
+
 {{/*
 Convert octal to decimal (e.g 644 => 420). For file permission modes, many people are more familiar with octal notation.
 However, due to yaml/json limitations, all the Kubernetes resources require file modes to be reported in decimal.
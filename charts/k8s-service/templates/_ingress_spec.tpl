@@ -0,0 +1,57 @@
+{{- /*
+Common Ingress resource that is shared between the main Ingress configured with the `ingress` input value and the
+additional Ingresses configured with the `ingresses` input value. This template requires a context with the Values,
+Release, Chart and Capabilities of the chart, along with:
+- ingressName: The name of the Ingress resource.
+- ingress: The Ingress configuration, following the schema of the `ingress` input value.
+When the GKE FrontendConfig is enabled, every Ingress is annotated to use it.
+*/ -}}
+{{- define "k8s-service.ingress" -}}
+{{- /*
+We declare some variables defined on the Values. These are reused in `with` and `range` blocks where the scoped variable
+(`.`) is rebound within the block.
+*/ -}}
+{{- $fullName := include "k8s-service.fullname" . -}}
+{{- $ingressAPIVersion := include "gruntwork.ingress.apiVersion" . -}}
+{{- $servicePort := .ingress.servicePort -}}
+{{- $baseVarsForBackend := dict "fullName" $fullName "ingressAPIVersion" $ingressAPIVersion -}}
+
+apiVersion: {{ $ingressAPIVersion }}
+kind: Ingress
+metadata:
+  name: {{ .ingressName }}
+  labels:
+    gruntwork.io/app-name: {{ .Values.applicationName }}
+    # These labels are required by helm. You can read more about required labels in the chart best practices guide:
+    # https://docs.helm.sh/chart_best_practices/#standard-labels
+    app.kubernetes.io/name: {{ include "k8s-service.name" . }}
+    helm.sh/chart: {{ include "k8s-service.chart" . }}
+    app.kubernetes.io/instance: {{ .Release.Name }}
+    app.kubernetes.io/managed-by: {{ .Release.Service }}
+{{- with merge (dict) (.ingress.annotations | default dict) (include "k8s-service.ingress.certManager.annotations" .ingress | fromYaml) (include "k8s-service.google.ingressAnnotations" . | fromYaml) }}
+  annotations:
+{{ toYaml . | indent 4 }}
+{{- end }}
+spec:
+  {{- if .ingress.ingressClassName }}
+  ingressClassName: {{ .ingress.ingressClassName }}
+  {{- end }}
+{{- with (include "k8s-service.ingress.tls" . | fromJsonArray) }}
+  tls:
+{{ toYaml . | indent 4}}
+{{- end }}
+  rules:
+    {{- range (include "k8s-service.ingress.rules" .ingress | fromJsonArray) }}
+    - {{ if .host }}host: {{ .host | quote }}
+      {{ end }}http:
+        paths:
+          {{- range .paths }}
+          - path: {{ .path }}
+            {{- if and (eq $ingressAPIVersion "networking.k8s.io/v1") .pathType }}
+            pathType: {{ .pathType }}
+            {{- end }}
+            backend:
+              {{- include "gruntwork.ingress.backend" (merge (dict) . (dict "servicePort" $servicePort) $baseVarsForBackend) }}
+          {{- end }}
+    {{- end }}
+{{- end -}}
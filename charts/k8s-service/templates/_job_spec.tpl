@@ -0,0 +1,96 @@
+{{- /*
+Common Job spec that is shared between the one-off Jobs and the CronJobs configured with the `jobs` input value. This
+is rendered at the indentation of the spec of a Job resource, so it must be indented further when it is embedded into a
+CronJob. This template requires the same context as `k8s-service.podSpec`, with isJob set to true.
+
+The Pods of the Job are labeled with their own `app.kubernetes.io/name` so that they are not selected by the Service,
+ServiceMonitor or PodDisruptionBudget of the main application.
+*/ -}}
+{{- define "k8s-service.jobSpec" -}}
+{{- if hasKey .job "backoffLimit" }}
+  backoffLimit: {{ .job.backoffLimit }}
+{{- end }}
+{{- if .job.activeDeadlineSeconds }}
+  activeDeadlineSeconds: {{ .job.activeDeadlineSeconds }}
+{{- end }}
+{{- if hasKey .job "ttlSecondsAfterFinished" }}
+  ttlSecondsAfterFinished: {{ .job.ttlSecondsAfterFinished }}
+{{- end }}
+  template:
+    metadata:
+      labels:
+        app.kubernetes.io/name: {{ include "k8s-service.jobName" (dict "context" . "jobName" .jobName) }}
+        app.kubernetes.io/instance: {{ .Release.Name }}
+        gruntwork.io/job-name: {{ .jobName }}
+        {{- range $key, $value := .Values.additionalPodLabels }}
+        {{ $key }}: "{{ $value }}"
+        {{- end }}
+
+      {{- with (merge (dict) (.job.podAnnotations | default dict) .Values.podAnnotations) }}
+      annotations:
+{{ toYaml . | indent 8 }}
+      {{- end }}
+    spec:
+{{- include "k8s-service.podSpec" . }}
+{{- end -}}
+
+{{- /*
+A Job, or a CronJob if the job config has a schedule, rendered from the job config. This is shared between the `jobs`
+and `migrations` input values. This template requires the same context as `k8s-service.podSpec`, with isJob set to
+true. The context must additionally include `Capabilities` so that the CronJob API version can be detected.
+*/ -}}
+{{- define "k8s-service.job" -}}
+{{- $name := include "k8s-service.jobName" (dict "context" . "jobName" .jobName) -}}
+{{- if .job.schedule }}
+apiVersion: {{ include "gruntwork.cronJob.apiVersion" . }}
+kind: CronJob
+{{- else }}
+apiVersion: batch/v1
+kind: Job
+{{- end }}
+metadata:
+  name: {{ $name }}
+  labels:
+    # These labels are required by helm. You can read more about required labels in the chart best practices guide:
+    # https://docs.helm.sh/chart_best_practices/#standard-labels
+    helm.sh/chart: {{ include "k8s-service.chart" . }}
+    app.kubernetes.io/name: {{ $name }}
+    app.kubernetes.io/instance: {{ .Release.Name }}
+    app.kubernetes.io/managed-by: {{ .Release.Service }}
+    gruntwork.io/job-name: {{ .jobName }}
+  {{- if or .job.annotations .job.helmHook }}
+  annotations:
+    {{- with .job.annotations }}
+{{ toYaml . | indent 4 }}
+    {{- end }}
+    {{- with .job.helmHook }}
+    "helm.sh/hook": {{ required "events is required on jobs helmHook" .events | join "," | quote }}
+    "helm.sh/hook-weight": {{ .weight | default 0 | quote }}
+    "helm.sh/hook-delete-policy": {{ .deletePolicy | default (list "before-hook-creation") | join "," | quote }}
+    {{- end }}
+  {{- end }}
+spec:
+{{- if .job.schedule }}
+  schedule: {{ .job.schedule | quote }}
+  {{- with .job.concurrencyPolicy }}
+  concurrencyPolicy: {{ . }}
+  {{- end }}
+  {{- with .job.startingDeadlineSeconds }}
+  startingDeadlineSeconds: {{ . }}
+  {{- end }}
+  {{- if hasKey .job "successfulJobsHistoryLimit" }}
+  successfulJobsHistoryLimit: {{ .job.successfulJobsHistoryLimit }}
+  {{- end }}
+  {{- if hasKey .job "failedJobsHistoryLimit" }}
+  failedJobsHistoryLimit: {{ .job.failedJobsHistoryLimit }}
+  {{- end }}
+  {{- if .job.suspend }}
+  suspend: true
+  {{- end }}
+  jobTemplate:
+    spec:
+{{ include "k8s-service.jobSpec" . | trimPrefix "\n" | indent 4 }}
+{{- else }}
+{{- include "k8s-service.jobSpec" . }}
+{{- end }}
+{{- end -}}
@@ -0,0 +1,595 @@
+{{- /*
+Common Pod spec that is shared between all the controllers managed by this chart: the canary and main Deployments, the
+StatefulSet and DaemonSet that replace the main Deployment, and the Jobs and CronJobs configured with `jobs`. This is
+rendered at the indentation of the Pod spec in the Deployment resource, so it must be indented further when it is
+embedded deeper into a resource (e.g CronJob). This template requires the context:
+- Values
+- Release
+- Chart
+- isCanary (a boolean indicating if we are rendering the canary deployment or not)
+- color (optional, the color of the Deployment when rendering the blue and green Deployments)
+- isJob (a boolean indicating if we are rendering the Pod of a Job)
+- jobName (the key of the job in the `jobs` input value, required when isJob is true)
+- job (the config of the job in the `jobs` input value, required when isJob is true)
+You can construct this context using dict:
+(dict "Values" .Values "Release" .Release "Chart" .Chart "isCanary" false "isJob" true "jobName" $name "job" $job)
+*/ -}}
+{{- define "k8s-service.podSpec" -}}
+{{- /*
+We must decide whether or not there are volumes to inject. The logic to decide whether or not to inject is based on
+whether or not there are configMaps OR secrets that are specified as volume mounts (`as: volume` attributes). We do this
+by using a map to track whether or not we have seen a volume type. We have to use a map because we can't update a
+variable in helm chart templates.
+
+Similarly, we need to decide whether or not there are environment variables to add
+
+We need this because certain sections are omitted if there are no volumes or environment variables to add.
+*/ -}}
+
+{{/* Go Templates do not support variable updating, so we simulate it using dictionaries */}}
+{{- $hasInjectionTypes := dict "hasVolume" false "hasEnvVars" false "exposePorts" false -}}
+{{- /*
+The downwardEnv preset exposes the common Pod identity fields as environment variables. Entries of envVars with the
+same name take precedence over the preset.
+*/ -}}
+{{- $envVars := merge (dict) .Values.envVars -}}
+{{- if .Values.downwardEnv -}}
+  {{- $envVars = merge $envVars (dict "POD_NAME" (dict "fieldRef" "metadata.name") "POD_NAMESPACE" (dict "fieldRef" "metadata.namespace") "POD_IP" (dict "fieldRef" "status.podIP") "NODE_NAME" (dict "fieldRef" "spec.nodeName")) -}}
+{{- end -}}
+{{- if $envVars -}}
+  {{- $_ := set $hasInjectionTypes "hasEnvVars" true -}}
+{{- end -}}
+{{- if .Values.additionalContainerEnv -}}
+  {{- $_ := set $hasInjectionTypes "hasEnvVars" true -}}
+{{- end -}}
+{{- /* The Vault Agent injector is only configured on the main and canary Pods, so we skip its env vars on Jobs */ -}}
+{{- $vaultEnvVars := dict -}}
+{{- if and .Values.vault.enabled (not .isJob) -}}
+  {{- range $name, $secret := .Values.vault.secrets -}}
+    {{- if $secret.envVarName -}}
+      {{- $_ := set $vaultEnvVars $secret.envVarName (printf "/vault/secrets/%s" $name) -}}
+    {{- end -}}
+  {{- end -}}
+{{- end -}}
+{{- if $vaultEnvVars -}}
+  {{- $_ := set $hasInjectionTypes "hasEnvVars" true -}}
+{{- end -}}
+{{- $allContainerPorts := values .Values.containerPorts -}}
+{{- range $allContainerPorts -}}
+  {{- if $.isJob -}}
+    {{- /* Jobs run to completion and are not routed to, so we never expose ports on them */ -}}
+  {{- else -}}
+  {{/* We are exposing ports if there is at least one key in containerPorts that is not disabled (disabled = false or
+       omitted)
+  */}}
+  {{- if or (not (hasKey . "disabled")) (not .disabled) -}}
+    {{- $_ := set $hasInjectionTypes "exposePorts" true -}}
+  {{- end -}}
+  {{- end -}}
+{{- end -}}
+{{- $allSecrets := values .Values.secrets -}}
+{{- range $allSecrets -}}
+  {{- if eq (index . "as") "volume" -}}
+    {{- $_ := set $hasInjectionTypes "hasVolume" true -}}
+  {{- else if eq (index . "as") "environment" -}}
+    {{- $_ := set $hasInjectionTypes "hasEnvVars" true -}}
+  {{- else if eq (index . "as") "csi" -}}
+    {{- $_ := set $hasInjectionTypes "hasEnvVars" true -}}
+    {{- $_ := set $hasInjectionTypes "hasVolume" true -}}
+  {{- else if eq (index . "as") "envFrom" }}
+    {{- $_ := set $hasInjectionTypes "hasEnvFrom" true -}}
+  {{- else if eq (index . "as") "none" -}}
+    {{- /* noop */ -}}
+  {{- else -}}
+    {{- fail printf "secrets config has unknown type: %s" (index . "as") -}}
+  {{- end -}}
+{{- end -}}
+{{- $allConfigMaps := values .Values.configMaps -}}
+{{- range $allConfigMaps -}}
+  {{- if eq (index . "as") "volume" -}}
+    {{- $_ := set $hasInjectionTypes "hasVolume" true -}}
+  {{- else if eq (index . "as") "environment" -}}
+    {{- $_ := set $hasInjectionTypes "hasEnvVars" true -}}
+  {{- else if eq (index . "as") "envFrom" }}
+    {{- $_ := set $hasInjectionTypes "hasEnvFrom" true -}}
+  {{- else if eq (index . "as") "none" -}}
+    {{- /* noop */ -}}
+  {{- else -}}
+    {{- fail printf "configMaps config has unknown type: %s" (index . "as") -}}
+  {{- end -}}
+{{- end -}}
+{{- if gt (len .Values.persistentVolumes) 0 -}}
+  {{- $_ := set $hasInjectionTypes "hasVolume" true -}}
+{{- end -}}
+{{- if gt (len .Values.scratchPaths) 0 -}}
+  {{- $_ := set $hasInjectionTypes "hasVolume" true -}}
+{{- end -}}
+{{- if gt (len .Values.emptyDirs) 0 -}}
+  {{- $_ := set $hasInjectionTypes "hasVolume" true -}}
+{{- end -}}
+{{- if gt (len .Values.hostPathVolumes) 0 -}}
+  {{- $_ := set $hasInjectionTypes "hasVolume" true -}}
+{{- end -}}
+{{- range $name, $value := .Values.volumes -}}
+  {{- $_ := set $hasInjectionTypes "hasVolume" true -}}
+  {{- $volumeSources := keys (omit $value "mountPath" "readOnly" "subPath" "subPathExpr" "mountPropagation") -}}
+  {{- if ne (len $volumeSources) 1 -}}
+    {{- fail (printf "volumes.%s must set exactly one volume source" $name) -}}
+  {{- end -}}
+  {{- if not (has (first $volumeSources) (list "projected" "downwardAPI" "emptyDir" "ephemeral" "nfs")) -}}
+    {{- fail (printf "volumes.%s has unknown volume source: %s" $name (first $volumeSources)) -}}
+  {{- end -}}
+{{- end -}}
+{{- /*
+The main container settings that can be overridden for each job. The job config takes precedence over the main
+container config when the key is set.
+*/ -}}
+{{- $containerConfig := dict "containerCommand" .Values.containerCommand "containerArgs" .Values.containerArgs "containerResources" .Values.containerResources -}}
+{{- if .isJob -}}
+  {{- range $key := keys $containerConfig -}}
+    {{- if hasKey $.job $key -}}
+      {{- $_ := set $containerConfig $key (index $.job $key) -}}
+    {{- end -}}
+  {{- end -}}
+{{- end -}}
+{{- if and (not .isJob) (eq (include "k8s-service.workloadType" .) "StatefulSet") (gt (len .Values.statefulSet.volumeClaimTemplates) 0) -}}
+  {{- $_ := set $hasInjectionTypes "hasVolumeClaimTemplate" true -}}
+{{- end -}}
+      {{- if gt (len .Values.serviceAccount.name) 0 }}
+      serviceAccountName: "{{ .Values.serviceAccount.name }}"
+      {{- end }}
+      {{- if hasKey .Values.serviceAccount "automountServiceAccountToken" }}
+      automountServiceAccountToken : {{ .Values.serviceAccount.automountServiceAccountToken }}
+      {{- end }}
+      {{- if .Values.podSecurityContext }}
+      securityContext:
+{{ toYaml .Values.podSecurityContext | indent 8 }}
+      {{- end}}
+      {{- if .Values.hostAliases }}
+      hostAliases:
+{{ toYaml .Values.hostAliases | indent 8 }}
+      {{- end }}
+
+      {{- if .Values.dnsPolicy }}
+      dnsPolicy: {{ .Values.dnsPolicy }}
+      {{- end }}
+
+      {{- if .Values.hostNetwork }}
+      hostNetwork: true
+      {{- end }}
+
+      {{- if .Values.hostPID }}
+      hostPID: true
+      {{- end }}
+
+      {{- if .isJob }}
+      restartPolicy: {{ .job.restartPolicy | default "Never" }}
+      {{- end }}
+
+      containers:
+        {{- if .isJob }}
+        - name: {{ .jobName }}
+          {{- $containerImage := merge (dict) (.job.containerImage | default dict) .Values.containerImage }}
+          {{- $repo := required ".Values.containerImage.repository is required" $containerImage.repository }}
+          {{- $tag := required ".Values.containerImage.tag is required" $containerImage.tag }}
+          image: "{{ $repo }}:{{ $tag }}"
+          imagePullPolicy: {{ $containerImage.pullPolicy | default "IfNotPresent" }}
+        {{- else if .isCanary }}
+        - name: {{ .Values.applicationName }}-canary
+          {{- $repo := required ".Values.canary.containerImage.repository is required" .Values.canary.containerImage.repository }}
+          {{- $tag := required ".Values.canary.containerImage.tag is required" .Values.canary.containerImage.tag }}
+          image: "{{ $repo }}:{{ $tag }}"
+          imagePullPolicy: {{ .Values.canary.containerImage.pullPolicy | default "IfNotPresent" }}
+        {{- else if .color }}
+        - name: {{ .Values.applicationName }}
+          {{- $containerImage := merge (dict) ((index .Values.blueGreen .color).containerImage | default dict) .Values.containerImage }}
+          {{- $repo := required ".Values.containerImage.repository is required" $containerImage.repository }}
+          {{- $tag := required (printf ".Values.blueGreen.%s.containerImage.tag is required" .color) $containerImage.tag }}
+          image: "{{ $repo }}:{{ $tag }}"
+          imagePullPolicy: {{ $containerImage.pullPolicy | default "IfNotPresent" }}
+        {{- else }}
+        - name: {{ .Values.applicationName }}
+          {{- $repo := required ".Values.containerImage.repository is required" .Values.containerImage.repository }}
+          {{- $tag := required ".Values.containerImage.tag is required" .Values.containerImage.tag }}
+          image: "{{ $repo }}:{{ $tag }}"
+          imagePullPolicy: {{ .Values.containerImage.pullPolicy | default "IfNotPresent" }}
+        {{- end }}
+          {{- with $containerConfig.containerCommand }}
+          command:
+{{ toYaml . | indent 12 }}
+          {{- end }}
+          {{- with $containerConfig.containerArgs }}
+          args:
+{{ toYaml . | indent 12 }}
+          {{- end }}
+          {{- if index $hasInjectionTypes "exposePorts" }}
+          ports:
+            {{- /*
+              NOTE: we check for a disabled flag here so that users of the helm
+              chart can override the default containerPorts. Specifically, defining a new
+              containerPorts in values.yaml will be merged with the default provided by the
+              chart. For example, if the user provides:
+
+                    containerPorts:
+                      app:
+                        port: 8080
+                        protocol: TCP
+
+              Then this is merged with the default and becomes:
+
+                    containerPorts:
+                      app:
+                        port: 8080
+                        protocol: TCP
+                      http:
+                        port: 80
+                        protocol: TCP
+                      https:
+                        port: 443
+                        protocol: TCP
+
+              and so it becomes append as opposed to replace. To handle this,
+              we allow users to explicitly disable predefined ports. So if the user wants to
+              replace the ports with their own, they would provide the following values file:
+
+                    containerPorts:
+                      app:
+                        port: 8080
+                        protocol: TCP
+                      http:
+                        disabled: true
+                      https:
+                        disabled: true
+            */ -}}
+            {{- range $key, $portSpec := .Values.containerPorts }}
+            {{- if not $portSpec.disabled }}
+            - name: {{ $key }}
+              containerPort: {{ int $portSpec.port }}
+              protocol: {{ $portSpec.protocol }}
+            {{- end }}
+            {{- end }}
+          {{- end }}
+
+          {{- if not .isJob }}
+          {{- if .Values.startupProbe }}
+          startupProbe:
+{{ toYaml .Values.startupProbe | indent 12 }}
+          {{- end }}
+
+          {{- if .Values.livenessProbe }}
+          livenessProbe:
+{{ toYaml .Values.livenessProbe | indent 12 }}
+          {{- end }}
+
+          {{- if .Values.readinessProbe }}
+          readinessProbe:
+{{ toYaml .Values.readinessProbe | indent 12 }}
+          {{- end }}
+          {{- end }}
+          {{- if .Values.securityContext }}
+          securityContext:
+{{ toYaml .Values.securityContext | indent 12 }}
+          {{- end}}
+          resources:
+{{ toYaml $containerConfig.containerResources | indent 12 }}
+
+          {{- if and (not .isJob) (or .Values.lifecycleHooks.enabled (gt (int .Values.shutdownDelay) 0)) }}
+          lifecycle:
+            {{- if and .Values.lifecycleHooks.enabled .Values.lifecycleHooks.postStart }}
+            postStart:
+{{ toYaml .Values.lifecycleHooks.postStart | indent 14 }}
+            {{- end }}
+
+            {{- if and .Values.lifecycleHooks.enabled .Values.lifecycleHooks.preStop }}
+            preStop:
+{{ toYaml .Values.lifecycleHooks.preStop | indent 14 }}
+            {{- else if gt (int .Values.shutdownDelay) 0 }}
+            # Include a preStop hook with a shutdown delay for eventual consistency reasons.
+            # See https://blog.gruntwork.io/delaying-shutdown-to-wait-for-pod-deletion-propagation-445f779a8304
+            preStop:
+              exec:
+                command:
+                  - sleep
+                  - "{{ int .Values.shutdownDelay }}"
+            {{- end }}
+
+          {{- end }}
+
+          {{- /* START ENV VAR LOGIC */ -}}
+          {{- if index $hasInjectionTypes "hasEnvVars" }}
+          env:
+          {{- end }}
+          {{- range $key, $value := $envVars }}
+            - name: {{ $key }}
+            {{- if kindIs "map" $value }}
+              valueFrom:
+              {{- if $value.fieldRef }}
+                fieldRef:
+                  fieldPath: {{ $value.fieldRef }}
+              {{- else if $value.resourceFieldRef }}
+                resourceFieldRef:
+                  resource: {{ $value.resourceFieldRef }}
+                  {{- with $value.containerName }}
+                  containerName: {{ . }}
+                  {{- end }}
+                  {{- with $value.divisor }}
+                  divisor: {{ quote . }}
+                  {{- end }}
+              {{- else }}
+                {{- fail (printf "envVars.%s must set either fieldRef or resourceFieldRef" $key) }}
+              {{- end }}
+            {{- else }}
+              value: {{ quote $value }}
+            {{- end }}
+          {{- end }}
+          {{- if .Values.additionalContainerEnv }}
+{{ toYaml .Values.additionalContainerEnv | indent 12 }}
+          {{- end }}
+          {{- range $envVarName, $filePath := $vaultEnvVars }}
+            - name: {{ $envVarName }}
+              value: {{ quote $filePath }}
+          {{- end }}
+          {{- range $name, $value := .Values.configMaps }}
+            {{- if eq $value.as "environment" }}
+            {{- range $configKey, $keyEnvVarConfig := $value.items }}
+            - name: {{ required "envVarName is required on configMaps items when using environment" $keyEnvVarConfig.envVarName | quote }}
+              valueFrom:
+                configMapKeyRef:
+                  name: {{ $name }}
+                  key: {{ $configKey }}
+                  {{- if ternary $keyEnvVarConfig.optional $value.optional (hasKey $keyEnvVarConfig "optional") }}
+                  optional: true
+                  {{- end }}
+            {{- end }}
+            {{- end }}
+          {{- end }}
+          {{- range $name, $value := .Values.secrets }}
+            {{- if or (eq $value.as "environment") (eq $value.as "csi") }}
+            {{- range $secretKey, $keyEnvVarConfig := $value.items }}
+            - name: {{ required "envVarName is required on secrets items when using environment or csi" $keyEnvVarConfig.envVarName | quote }}
+              valueFrom:
+                secretKeyRef:
+                  name: {{ $name }}
+                  key: {{ $secretKey }}
+                  {{- if ternary $keyEnvVarConfig.optional $value.optional (hasKey $keyEnvVarConfig "optional") }}
+                  optional: true
+                  {{- end }}
+            {{- end }}
+            {{- end }}
+          {{- end }}
+          {{- if index $hasInjectionTypes "hasEnvFrom" }}
+          envFrom:
+          {{- range $name, $value := .Values.configMaps }}
+            {{- if eq $value.as "envFrom" }}
+            - configMapRef:
+                name: {{ $name }}
+                {{- if $value.optional }}
+                optional: true
+                {{- end }}
+              {{- with $value.prefix }}
+              prefix: {{ quote . }}
+              {{- end }}
+            {{- end }}
+          {{- end }}
+          {{- range $name, $value := .Values.secrets }}
+            {{- if eq $value.as "envFrom" }}
+            - secretRef:
+                name: {{ $name }}
+                {{- if $value.optional }}
+                optional: true
+                {{- end }}
+              {{- with $value.prefix }}
+              prefix: {{ quote . }}
+              {{- end }}
+            {{- end }}
+          {{- end }}
+          {{- end }}
+          {{- /* END ENV VAR LOGIC */ -}}
+
+
+          {{- /* START VOLUME MOUNT LOGIC */ -}}
+          {{- if or (index $hasInjectionTypes "hasVolume") (index $hasInjectionTypes "hasVolumeClaimTemplate") }}
+          volumeMounts:
+          {{- end }}
+          {{- range $name, $value := .Values.configMaps }}
+            {{- if eq $value.as "volume" }}
+            - name: {{ $name }}-volume
+              mountPath: {{ quote $value.mountPath }}
+              {{- if $value.subPath }}
+              subPath: {{ quote $value.subPath }}
+              {{- end }}
+            {{- end }}
+          {{- end }}
+          {{- range $name, $value := .Values.secrets }}
+            {{- if or (eq $value.as "volume") (eq $value.as "csi") }}
+            - name: {{ $name }}-volume
+              mountPath: {{ quote $value.mountPath }}
+              {{- if $value.subPath }}
+              subPath: {{ quote $value.subPath }}
+              {{- end }}
+              readOnly: {{ $value.readOnly }}
+            {{- end }}
+          {{- end }}
+          {{- range $name, $value := .Values.persistentVolumes }}
+            - name: {{ $name }}
+              mountPath: {{ quote $value.mountPath }}
+          {{- end }}
+          {{- range $name, $value := .Values.scratchPaths }}
+            - name: {{ $name }}
+              mountPath: {{ quote $value }}
+          {{- end }}
+          {{- range $name, $value := .Values.emptyDirs }}
+            - name: {{ $name }}
+              mountPath: {{ quote $value }}
+          {{- end }}
+          {{- range $name, $value := .Values.hostPathVolumes }}
+            - name: {{ $name }}
+              mountPath: {{ required "mountPath is required on hostPathVolumes entries" $value.mountPath | quote }}
+              {{- if $value.readOnly }}
+              readOnly: true
+              {{- end }}
+              {{- if $value.mountPropagation }}
+              mountPropagation: {{ $value.mountPropagation }}
+              {{- end }}
+          {{- end }}
+          {{- range $name, $value := .Values.volumes }}
+            - name: {{ $name }}
+              mountPath: {{ required "mountPath is required on volumes entries" $value.mountPath | quote }}
+              {{- if $value.readOnly }}
+              readOnly: true
+              {{- end }}
+              {{- if $value.subPath }}
+              subPath: {{ quote $value.subPath }}
+              {{- end }}
+              {{- if $value.subPathExpr }}
+              subPathExpr: {{ quote $value.subPathExpr }}
+              {{- end }}
+              {{- if $value.mountPropagation }}
+              mountPropagation: {{ $value.mountPropagation }}
+              {{- end }}
+          {{- end }}
+          {{- if index $hasInjectionTypes "hasVolumeClaimTemplate" }}
+          {{- range $name, $value := .Values.statefulSet.volumeClaimTemplates }}
+            - name: {{ $name }}
+              mountPath: {{ required "mountPath is required on statefulSet.volumeClaimTemplates entries" $value.mountPath | quote }}
+              {{- if $value.subPath }}
+              subPath: {{ quote $value.subPath }}
+              {{- end }}
+          {{- end }}
+          {{- end }}
+          {{- /* END VOLUME MOUNT LOGIC */ -}}
+
+        {{- /* Side car containers never exit, which would prevent Jobs from completing */ -}}
+        {{- if not .isJob }}
+        {{- range $key, $value := .Values.sideCarContainers }}
+        - name: {{ $key }}
+{{ toYaml $value | indent 10 }}
+        {{- end }}
+        {{- end }}
+
+
+    {{- if gt (len .Values.initContainers) 0 }}
+      initContainers:
+        {{- range $key, $value := .Values.initContainers }}
+        - name: {{ $key }}
+{{ toYaml $value | indent 10 }}
+        {{- end }}
+    {{- end }}
+
+    {{- /* START IMAGE PULL SECRETS LOGIC */ -}}
+    {{- $imagePullSecrets := include "k8s-service.imagePullSecrets" . | fromJsonArray }}
+    {{- if gt (len $imagePullSecrets) 0 }}
+      imagePullSecrets:
+        {{- range $secretName := $imagePullSecrets }}
+        - name: {{ $secretName }}
+        {{- end }}
+    {{- end }}
+    {{- /* END IMAGE PULL SECRETS LOGIC */ -}}
+
+    {{- /* START TERMINATION GRACE PERIOD LOGIC */ -}}
+    {{- if .Values.terminationGracePeriodSeconds }}
+      terminationGracePeriodSeconds: {{ .Values.terminationGracePeriodSeconds }}
+    {{- end}}
+    {{- /* END TERMINATION GRACE PERIOD LOGIC */ -}}
+
+    {{- /* START VOLUME LOGIC */ -}}
+    {{- if index $hasInjectionTypes "hasVolume" }}
+      volumes:
+    {{- end }}
+    {{- range $name, $value := .Values.configMaps }}
+      {{- if eq $value.as "volume" }}
+        - name: {{ $name }}-volume
+          configMap:
+            name: {{ $name }}
+            {{- if $value.optional }}
+            optional: true
+            {{- end }}
+            {{- if $value.items }}
+            items:
+              {{- range $configKey, $keyMountConfig := $value.items }}
+              - key: {{ $configKey }}
+                path: {{ required "filePath is required for configMap items" $keyMountConfig.filePath | quote }}
+                {{- if $keyMountConfig.fileMode }}
+                mode: {{ include "k8s-service.fileModeOctalToDecimal" $keyMountConfig.fileMode }}
+                {{- end }}
+              {{- end }}
+            {{- end }}
+      {{- end }}
+    {{- end }}
+    {{- range $name, $value := .Values.secrets }}
+      {{- if eq $value.as "volume" }}
+        - name: {{ $name }}-volume
+          secret:
+            secretName: {{ $name }}
+            {{- if $value.optional }}
+            optional: true
+            {{- end }}
+            {{- if $value.items }}
+            items:
+              {{- range $secretKey, $keyMountConfig := $value.items }}
+              - key: {{ $secretKey }}
+                path: {{ required "filePath is required for secrets items" $keyMountConfig.filePath | quote }}
+                {{- if $keyMountConfig.fileMode }}
+                mode: {{ include "k8s-service.fileModeOctalToDecimal" $keyMountConfig.fileMode }}
+                {{- end }}
+              {{- end }}
+            {{- end }}
+      {{- end }}
+      {{- if eq $value.as "csi" }}
+        - name: {{ $name }}-volume
+          csi: 
+            readOnly: {{ $value.readOnly }}
+            driver:  {{ $value.csi.driver }}
+            volumeAttributes:
+              secretProviderClass: {{ $value.csi.secretProviderClass | default $name }}
+
+      {{- end }}    
+    {{- end }}
+    {{- range $name, $value := .Values.persistentVolumes }}
+        - name: {{ $name }}
+          persistentVolumeClaim:
+            claimName: {{ include "k8s-service.persistentVolume.claimName" (dict "context" $ "name" $name "volume" $value) }}
+    {{- end }}
+    {{- range $name, $value := .Values.scratchPaths }}
+        - name: {{ $name }}
+          emptyDir:
+            medium: "Memory"
+    {{- end }}
+    {{- range $name, $value := .Values.emptyDirs }}
+        - name: {{ $name }}
+          emptyDir: {}
+    {{- end }}
+    {{- range $name, $value := .Values.hostPathVolumes }}
+        - name: {{ $name }}
+          hostPath:
+            path: {{ required "path is required on hostPathVolumes entries" $value.path | quote }}
+            {{- if $value.type }}
+            type: {{ $value.type }}
+            {{- end }}
+    {{- end }}
+    {{- range $name, $value := .Values.volumes }}
+        - name: {{ $name }}
+{{ toYaml (omit $value "mountPath" "readOnly" "subPath" "subPathExpr" "mountPropagation") | indent 10 }}
+    {{- end }}
+    {{- /* END VOLUME LOGIC */ -}}
+
+    {{- with .Values.nodeSelector }}
+      nodeSelector:
+{{ toYaml . | indent 8 }}
+    {{- end }}
+
+    {{- with .Values.affinity }}
+      affinity:
+{{ toYaml . | indent 8 }}
+    {{- end }}
+
+    {{- with .Values.priorityClassName }}
+      priorityClassName:
+{{ toYaml . | indent 8 }}
+    {{- end }}
+
+    {{- with .Values.tolerations }}
+      tolerations:
+{{ toYaml . | indent 8 }}
+    {{- end }}
+{{- end -}}
@@ -0,0 +1,204 @@
+//go:build all || tpl
+// +build all tpl
+
+// NOTE: We use build flags to differentiate between template tests and integration tests so that you can conveniently
+// run just the template tests. See the test README for more information.
+
+package test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/helm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that enabling blue/green deployments renders a blue and a green Deployment, each with its own image tag
+func TestK8SServiceBlueGreenRendersBlueAndGreenDeployments(t *testing.T) {
+	t.Parallel()
+
+	deployments := renderK8SServiceBlueGreenDeploymentsWithSetValues(
+		t,
+		map[string]string{
+			"blueGreen.enabled":                  "true",
+			"blueGreen.active":                   "blue",
+			"blueGreen.blue.containerImage.tag":  "1.16.0",
+			"blueGreen.green.containerImage.tag": "1.17.0",
+			"replicaCount":                       "3",
+			"blueGreen.green.replicaCount":       "1",
+		},
+	)
+	require.Equal(t, len(deployments), 2)
+
+	blue := deployments[0]
+	assert.Equal(t, "bluegreen-linter-blue", blue.Name)
+	assert.Equal(t, "blue", blue.Spec.Selector.MatchLabels["gruntwork.io/deployment-color"])
+	assert.Equal(t, "blue", blue.Spec.Template.Labels["gruntwork.io/deployment-color"])
+	assert.Equal(t, int32(3), *blue.Spec.Replicas)
+	require.Equal(t, len(blue.Spec.Template.Spec.Containers), 1)
+	assert.Equal(t, "nginx:1.16.0", blue.Spec.Template.Spec.Containers[0].Image)
+
+	green := deployments[1]
+	assert.Equal(t, "bluegreen-linter-green", green.Name)
+	assert.Equal(t, "green", green.Spec.Selector.MatchLabels["gruntwork.io/deployment-color"])
+	assert.Equal(t, "green", green.Spec.Template.Labels["gruntwork.io/deployment-color"])
+	assert.Equal(t, int32(1), *green.Spec.Replicas)
+	require.Equal(t, len(green.Spec.Template.Spec.Containers), 1)
+	assert.Equal(t, "nginx:1.17.0", green.Spec.Template.Spec.Containers[0].Image)
+}
+
+// Test that the Service routes to the active color and the preview Service routes to the inactive color
+func TestK8SServiceBlueGreenActiveFlipsServiceSelectors(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		active  string
+		preview string
+	}{
+		{"blue", "green"},
+		{"green", "blue"},
+	}
+
+	for _, testCase := range testCases {
+		// Capture range variable to force scope
+		testCase := testCase
+		t.Run(testCase.active, func(t *testing.T) {
+			t.Parallel()
+
+			values := map[string]string{
+				"blueGreen.enabled": "true",
+				"blueGreen.active":  testCase.active,
+			}
+
+			service := renderK8SServiceWithSetValues(t, values)
+			assert.Equal(t, testCase.active, service.Spec.Selector["gruntwork.io/deployment-color"])
+
+			previewService := renderK8SServicePreviewServiceWithSetValues(t, values)
+			assert.Equal(t, "service-linter-preview", previewService.Name)
+			assert.Equal(t, testCase.preview, previewService.Spec.Selector["gruntwork.io/deployment-color"])
+			assert.Equal(t, "linter", previewService.Spec.Selector["app.kubernetes.io/name"])
+		})
+	}
+}
+
+// Test that the Horizontal Pod Autoscaler targets the Deployment of the active color
+func TestK8SServiceBlueGreenHorizontalPodAutoscalerTargetsActiveColor(t *testing.T) {
+	t.Parallel()
+
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values defined.
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+		SetValues: map[string]string{
+			"blueGreen.enabled":               "true",
+			"blueGreen.active":                "green",
+			"horizontalPodAutoscaler.enabled": "true",
+		},
+	}
+	out := helm.RenderTemplate(t, options, helmChartPath, "hpa", []string{"templates/horizontalpodautoscaler.yaml"})
+	assert.True(t, strings.Contains(out, "name: hpa-linter-green"))
+
+	// The inactive color is not managed by the autoscaler, so its replicas are always set
+	deployments := renderK8SServiceBlueGreenDeploymentsWithSetValues(t, options.SetValues)
+	require.Equal(t, len(deployments), 2)
+	assert.Equal(t, int32(1), *deployments[0].Spec.Replicas)
+	assert.Nil(t, deployments[1].Spec.Replicas)
+}
+
+// Test that flipping the active color with the Horizontal Pod Autoscaler enabled keeps the replicas of the pre-scaled
+// inactive color, and leaves the replicas of the active color to the autoscaler. The replicas of the active color are
+// read from the cluster, so they are never rendered by helm template.
+func TestK8SServiceBlueGreenHorizontalPodAutoscalerFlipsActiveColor(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		active   string
+		inactive string
+	}{
+		{"blue", "green"},
+		{"green", "blue"},
+	}
+
+	for _, testCase := range testCases {
+		// Capture range variable to force scope
+		testCase := testCase
+		t.Run(testCase.active, func(t *testing.T) {
+			t.Parallel()
+
+			deployments := renderK8SServiceBlueGreenDeploymentsWithSetValues(
+				t,
+				map[string]string{
+					"blueGreen.enabled":               "true",
+					"blueGreen.active":                testCase.active,
+					"blueGreen.blue.replicaCount":     "5",
+					"blueGreen.green.replicaCount":    "5",
+					"horizontalPodAutoscaler.enabled": "true",
+				},
+			)
+			require.Equal(t, len(deployments), 2)
+
+			for _, deployment := range deployments {
+				if deployment.Name == "bluegreen-linter-"+testCase.active {
+					assert.Nil(t, deployment.Spec.Replicas)
+				} else {
+					assert.Equal(t, "bluegreen-linter-"+testCase.inactive, deployment.Name)
+					require.NotNil(t, deployment.Spec.Replicas)
+					assert.Equal(t, int32(5), *deployment.Spec.Replicas)
+				}
+			}
+		})
+	}
+}
+
+// Test that blue/green deployments can not be combined with an unknown active color or a canary
+func TestK8SServiceBlueGreenInvalidConfigFails(t *testing.T) {
+	t.Parallel()
+
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name          string
+		setValues     map[string]string
+		expectedError string
+	}{
+		{
+			"unknown-active",
+			map[string]string{"blueGreen.enabled": "true", "blueGreen.active": "red"},
+			"blueGreen.active has unknown value: red",
+		},
+		{
+			"canary",
+			map[string]string{
+				"blueGreen.enabled":                "true",
+				"canary.enabled":                   "true",
+				"canary.containerImage.repository": "nginx",
+				"canary.containerImage.tag":        "1.17.0",
+			},
+			"canary can not be used together with blueGreen",
+		},
+	}
+
+	for _, testCase := range testCases {
+		// Capture range variable to force scope
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values
+			// defined.
+			options := &helm.Options{
+				ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+				SetValues:   testCase.setValues,
+			}
+			_, err := helm.RenderTemplateE(t, options, helmChartPath, "bluegreen", []string{})
+			require.Error(t, err)
+			assert.True(t, strings.Contains(err.Error(), testCase.expectedError))
+		})
+	}
+}
@@ -0,0 +1,223 @@
+//go:build all || tpl
+// +build all tpl
+
+// NOTE: We use build flags to differentiate between template tests and integration tests so that you can conveniently
+// run just the template tests. See the test README for more information.
+
+package test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/helm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var canaryTrafficWeightBaseValues = map[string]string{
+	"canary.enabled":                   "true",
+	"canary.containerImage.repository": "nginx",
+	"canary.containerImage.tag":        "1.16.0",
+	"service.enabled":                  "true",
+	"service.ports.app.port":           "80",
+	"service.ports.app.targetPort":     "http",
+	"ingress.enabled":                  "true",
+	"ingress.path":                     "/app",
+	"ingress.pathType":                 "Prefix",
+	"ingress.servicePort":              "app",
+	"ingress.hosts[0]":                 "chart-example.local",
+}
+
+func canaryTrafficWeightValues(setValues map[string]string) map[string]string {
+	values := map[string]string{}
+	for key, value := range canaryTrafficWeightBaseValues {
+		values[key] = value
+	}
+	for key, value := range setValues {
+		values[key] = value
+	}
+	return values
+}
+
+// Test that the canary Service and Ingress are not rendered when the canary does not configure a traffic weight, and
+// that the main Service keeps selecting both the main and canary Pods.
+func TestK8SServiceCanaryWithoutTrafficWeightDoesNotRenderCanaryServiceOrIngress(t *testing.T) {
+	t.Parallel()
+
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values defined.
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+		SetValues:   canaryTrafficWeightValues(map[string]string{}),
+	}
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "service", []string{"templates/canaryservice.yaml"})
+	require.Error(t, err)
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "ingress", []string{"templates/canaryingress.yaml"})
+	require.Error(t, err)
+
+	service := renderK8SServiceWithSetValues(t, canaryTrafficWeightValues(map[string]string{}))
+	_, hasDeploymentType := service.Spec.Selector["gruntwork.io/deployment-type"]
+	assert.False(t, hasDeploymentType)
+}
+
+// Test that setting a traffic weight restricts the main Service to the main Pods and creates a canary Service that
+// selects only the canary Pods.
+func TestK8SServiceCanaryTrafficWeightSplitsServices(t *testing.T) {
+	t.Parallel()
+
+	values := canaryTrafficWeightValues(map[string]string{"canary.trafficWeight": "20"})
+
+	service := renderK8SServiceWithSetValues(t, values)
+	assert.Equal(t, "main", service.Spec.Selector["gruntwork.io/deployment-type"])
+
+	canaryService := renderK8SServiceCanaryServiceWithSetValues(t, values)
+	assert.Equal(t, "service-linter-canary", canaryService.Name)
+	assert.Equal(t, "canary", canaryService.Spec.Selector["gruntwork.io/deployment-type"])
+	assert.Equal(t, "linter", canaryService.Spec.Selector["app.kubernetes.io/name"])
+	require.Equal(t, len(canaryService.Spec.Ports), 1)
+	assert.Equal(t, "app", canaryService.Spec.Ports[0].Name)
+	assert.Equal(t, int32(80), canaryService.Spec.Ports[0].Port)
+}
+
+// Test that setting a traffic weight creates a canary Ingress with the ingress-nginx canary annotations that routes to
+// the canary Service.
+func TestK8SServiceCanaryTrafficWeightRendersCanaryIngress(t *testing.T) {
+	t.Parallel()
+
+	ingress := renderK8SServiceCanaryIngressWithSetValues(
+		t,
+		canaryTrafficWeightValues(map[string]string{
+			"kubeVersionOverride":                                 "1.25.0",
+			"canary.trafficWeight":                                "20",
+			"canary.trafficHeader":                                "X-Canary",
+			"canary.trafficCookie":                                "canary",
+			"ingress.annotations.kubernetes\\.io/ingress\\.class": "nginx",
+		}),
+	)
+
+	assert.Equal(t, "ingress-linter-canary", ingress.Name)
+	assert.Equal(t, "true", ingress.Annotations["nginx.ingress.kubernetes.io/canary"])
+	assert.Equal(t, "20", ingress.Annotations["nginx.ingress.kubernetes.io/canary-weight"])
+	assert.Equal(t, "X-Canary", ingress.Annotations["nginx.ingress.kubernetes.io/canary-by-header"])
+	assert.Equal(t, "canary", ingress.Annotations["nginx.ingress.kubernetes.io/canary-by-cookie"])
+	assert.Equal(t, "nginx", ingress.Annotations["kubernetes.io/ingress.class"])
+
+	require.Equal(t, len(ingress.Spec.Rules), 1)
+	rule := ingress.Spec.Rules[0]
+	assert.Equal(t, "chart-example.local", rule.Host)
+	require.Equal(t, len(rule.HTTP.Paths), 1)
+	path := rule.HTTP.Paths[0]
+	assert.Equal(t, "/app", path.Path)
+	assert.Equal(t, "ingress-linter-canary", path.Backend.Service.Name)
+	assert.Equal(t, "app", path.Backend.Service.Port.Name)
+}
+
+// Test that the canary Ingress mirrors the paths of ingress.rules that route to the application Service
+func TestK8SServiceCanaryTrafficWeightMirrorsIngressRules(t *testing.T) {
+	t.Parallel()
+
+	ingress := renderK8SServiceCanaryIngressWithSetValues(
+		t,
+		canaryTrafficWeightValues(map[string]string{
+			"kubeVersionOverride":                   "1.25.0",
+			"canary.trafficWeight":                  "20",
+			"ingress.rules[0].host":                 "api.example.com",
+			"ingress.rules[0].paths[0].path":        "/v1",
+			"ingress.rules[0].paths[0].pathType":    "Prefix",
+			"ingress.rules[0].paths[1].path":        "/admin",
+			"ingress.rules[0].paths[1].pathType":    "Prefix",
+			"ingress.rules[0].paths[1].serviceName": "admin",
+			"ingress.rules[0].paths[1].servicePort": "8080",
+			"ingress.rules[1].host":                 "admin.example.com",
+			"ingress.rules[1].paths[0].path":        "/",
+			"ingress.rules[1].paths[0].pathType":    "Prefix",
+			"ingress.rules[1].paths[0].serviceName": "admin",
+			"ingress.rules[1].paths[0].servicePort": "8080",
+		}),
+	)
+
+	require.Equal(t, len(ingress.Spec.Rules), 1)
+	rule := ingress.Spec.Rules[0]
+	assert.Equal(t, "api.example.com", rule.Host)
+	require.Equal(t, len(rule.HTTP.Paths), 1)
+	path := rule.HTTP.Paths[0]
+	assert.Equal(t, "/v1", path.Path)
+	assert.Equal(t, "ingress-linter-canary", path.Backend.Service.Name)
+	assert.Equal(t, "app", path.Backend.Service.Port.Name)
+}
+
+// Test that the canary Ingress mirrors the additional paths of the ingress input value that route to the application
+// Service, in the same order as the main Ingress.
+func TestK8SServiceCanaryTrafficWeightMirrorsAdditionalPaths(t *testing.T) {
+	t.Parallel()
+
+	ingress := renderK8SServiceCanaryIngressWithSetValues(
+		t,
+		canaryTrafficWeightValues(map[string]string{
+			"kubeVersionOverride":                                  "1.25.0",
+			"canary.trafficWeight":                                 "20",
+			"ingress.additionalPathsHigherPriority[0].path":        "/healthz",
+			"ingress.additionalPathsHigherPriority[0].pathType":    "Exact",
+			"ingress.additionalPathsHigherPriority[0].servicePort": "app",
+			"ingress.additionalPaths[0].path":                      "/static",
+			"ingress.additionalPaths[0].pathType":                  "Prefix",
+			"ingress.additionalPaths[0].serviceName":               "static",
+			"ingress.additionalPaths[0].servicePort":               "80",
+			"ingress.additionalPaths[1].path":                      "/api",
+			"ingress.additionalPaths[1].pathType":                  "Prefix",
+			"ingress.additionalPaths[1].servicePort":               "app",
+		}),
+	)
+
+	require.Equal(t, len(ingress.Spec.Rules), 1)
+	rule := ingress.Spec.Rules[0]
+	assert.Equal(t, "chart-example.local", rule.Host)
+	require.Equal(t, len(rule.HTTP.Paths), 3)
+	for index, expectedPath := range []string{"/healthz", "/app", "/api"} {
+		path := rule.HTTP.Paths[index]
+		assert.Equal(t, expectedPath, path.Path)
+		assert.Equal(t, "ingress-linter-canary", path.Backend.Service.Name)
+		assert.Equal(t, "app", path.Backend.Service.Port.Name)
+	}
+}
+
+// Test that a traffic weight fails to render when the Ingress is disabled, as no traffic would reach the canary Pods
+func TestK8SServiceCanaryTrafficWeightRequiresIngress(t *testing.T) {
+	t.Parallel()
+
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values defined.
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+		SetValues: canaryTrafficWeightValues(map[string]string{
+			"canary.trafficWeight": "20",
+			"ingress.enabled":      "false",
+		}),
+	}
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "service", []string{"templates/service.yaml"})
+	require.Error(t, err)
+	assert.True(t, strings.Contains(err.Error(), "canary.trafficWeight requires ingress.enabled"))
+}
+
+// Test that a traffic weight outside of the percentage range fails to render
+func TestK8SServiceCanaryTrafficWeightOutOfRangeFails(t *testing.T) {
+	t.Parallel()
+
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values defined.
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+		SetValues:   canaryTrafficWeightValues(map[string]string{"canary.trafficWeight": "120"}),
+	}
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "ingress", []string{"templates/canaryingress.yaml"})
+	require.Error(t, err)
+	assert.True(t, strings.Contains(err.Error(), "canary.trafficWeight must be a percentage between 0 and 100"))
+}
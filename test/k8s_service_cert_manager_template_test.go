@@ -0,0 +1,227 @@
+//go:build all || tpl
+// +build all tpl
+
+// NOTE: We use build flags to differentiate between template tests and integration tests so that you can conveniently
+// run just the template tests. See the test README for more information.
+
+package test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"github.com/gruntwork-io/terratest/modules/helm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var certManagerBaseValues = map[string]string{
+	"ingress.enabled":     "true",
+	"ingress.path":        "/",
+	"ingress.pathType":    "Prefix",
+	"ingress.servicePort": "app",
+	"ingress.hosts[0]":    "chart-example.local",
+	"ingress.hosts[1]":    "www.chart-example.local",
+}
+
+func certManagerValues(setValues map[string]string) map[string]string {
+	values := map[string]string{}
+	for key, value := range certManagerBaseValues {
+		values[key] = value
+	}
+	for key, value := range setValues {
+		values[key] = value
+	}
+	return values
+}
+
+// renderK8SServiceCertificatesWithSetValues renders the cert-manager Certificate resources and returns them keyed by name
+// as maps, since there are no Go types available for the cert-manager resources in this module.
+func renderK8SServiceCertificatesWithSetValues(t *testing.T, setValues map[string]string) map[string]map[string]interface{} {
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values defined.
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+		SetValues:   setValues,
+	}
+	out := helm.RenderTemplate(t, options, helmChartPath, "certificates", []string{"templates/certificates.yaml"})
+
+	certificates := map[string]map[string]interface{}{}
+	for _, document := range splitYamlDocuments(out) {
+		rendered := map[string]interface{}{}
+		require.NoError(t, yaml.Unmarshal([]byte(document), &rendered))
+		assert.Equal(t, "cert-manager.io/v1", rendered["apiVersion"])
+		assert.Equal(t, "Certificate", rendered["kind"])
+		name := rendered["metadata"].(map[string]interface{})["name"].(string)
+		certificates[name] = rendered
+	}
+	return certificates
+}
+
+// Test that the cert-manager annotations are added to the Ingress, with a generated TLS configuration covering all the
+// hosts, and that no Certificate is rendered in annotation mode
+func TestK8SServiceCertManagerAnnotationMode(t *testing.T) {
+	t.Parallel()
+
+	setValues := certManagerValues(map[string]string{
+		"ingress.certManager.enabled":       "true",
+		"ingress.certManager.clusterIssuer": "letsencrypt",
+		"ingress.certManager.duration":      "2160h",
+		"ingress.certManager.renewBefore":   "360h",
+	})
+	ingress := renderK8SServiceIngressWithSetValues(t, setValues)
+	assert.Equal(t, "letsencrypt", ingress.Annotations["cert-manager.io/cluster-issuer"])
+	assert.Equal(t, "2160h", ingress.Annotations["cert-manager.io/duration"])
+	assert.Equal(t, "360h", ingress.Annotations["cert-manager.io/renew-before"])
+	assert.NotContains(t, ingress.Annotations, "cert-manager.io/issuer")
+
+	require.Equal(t, 1, len(ingress.Spec.TLS))
+	assert.Equal(t, "ingress-linter-tls", ingress.Spec.TLS[0].SecretName)
+	assert.Equal(t, []string{"chart-example.local", "www.chart-example.local"}, ingress.Spec.TLS[0].Hosts)
+
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values defined.
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+		SetValues:   setValues,
+	}
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "certificates", []string{"templates/certificates.yaml"})
+	require.Error(t, err)
+}
+
+// Test that the tls of the ingress input value is kept as is when set, and that a namespaced issuer is annotated
+func TestK8SServiceCertManagerAnnotationModeKeepsTLS(t *testing.T) {
+	t.Parallel()
+
+	ingress := renderK8SServiceIngressWithSetValues(
+		t,
+		certManagerValues(map[string]string{
+			"ingress.certManager.enabled": "true",
+			"ingress.certManager.issuer":  "selfsigned",
+			"ingress.tls[0].secretName":   "chart-example-tls",
+			"ingress.tls[0].hosts[0]":     "chart-example.local",
+			"ingress.annotations.team":    "web",
+		}),
+	)
+	assert.Equal(t, "selfsigned", ingress.Annotations["cert-manager.io/issuer"])
+	assert.Equal(t, "web", ingress.Annotations["team"])
+	assert.NotContains(t, ingress.Annotations, "cert-manager.io/cluster-issuer")
+
+	require.Equal(t, 1, len(ingress.Spec.TLS))
+	assert.Equal(t, "chart-example-tls", ingress.Spec.TLS[0].SecretName)
+	assert.Equal(t, []string{"chart-example.local"}, ingress.Spec.TLS[0].Hosts)
+}
+
+// Test that a Certificate is rendered for the generated TLS configuration in Certificate mode, and that the cert-manager
+// annotations are not added to the Ingress
+func TestK8SServiceCertManagerCertificateMode(t *testing.T) {
+	t.Parallel()
+
+	setValues := certManagerValues(map[string]string{
+		"ingress.certManager.enabled":           "true",
+		"ingress.certManager.createCertificate": "true",
+		"ingress.certManager.issuer":            "selfsigned",
+		"ingress.certManager.duration":          "2160h",
+		"ingress.certManager.renewBefore":       "360h",
+	})
+	ingress := renderK8SServiceIngressWithSetValues(t, setValues)
+	assert.NotContains(t, ingress.Annotations, "cert-manager.io/issuer")
+	require.Equal(t, 1, len(ingress.Spec.TLS))
+	// The Secret is named after the Ingress, which is prefixed with the release name of the render helper
+	assert.Equal(t, "ingress-linter-tls", ingress.Spec.TLS[0].SecretName)
+
+	certificates := renderK8SServiceCertificatesWithSetValues(t, setValues)
+	require.Equal(t, 1, len(certificates))
+	require.Contains(t, certificates, "certificates-linter-tls")
+	spec := certificates["certificates-linter-tls"]["spec"].(map[string]interface{})
+	assert.Equal(t, "certificates-linter-tls", spec["secretName"])
+	assert.Equal(t, []interface{}{"chart-example.local", "www.chart-example.local"}, spec["dnsNames"])
+	assert.Equal(
+		t,
+		map[string]interface{}{"name": "selfsigned", "kind": "Issuer", "group": "cert-manager.io"},
+		spec["issuerRef"],
+	)
+	assert.Equal(t, "2160h", spec["duration"])
+	assert.Equal(t, "360h", spec["renewBefore"])
+}
+
+// Test that a Certificate is rendered for each TLS entry in Certificate mode, including for the additional Ingresses
+func TestK8SServiceCertManagerCertificateModePerTLSEntry(t *testing.T) {
+	t.Parallel()
+
+	certificates := renderK8SServiceCertificatesWithSetValues(
+		t,
+		certManagerValues(map[string]string{
+			"ingress.certManager.enabled":                    "true",
+			"ingress.certManager.createCertificate":          "true",
+			"ingress.certManager.clusterIssuer":              "letsencrypt",
+			"ingress.tls[0].secretName":                      "chart-example-tls",
+			"ingress.tls[0].hosts[0]":                        "chart-example.local",
+			"ingress.tls[1].secretName":                      "www-chart-example-tls",
+			"ingress.tls[1].hosts[0]":                        "www.chart-example.local",
+			"ingresses.public.rules[0].host":                 "chart-example.com",
+			"ingresses.public.rules[0].paths[0].path":        "/",
+			"ingresses.public.rules[0].paths[0].pathType":    "Prefix",
+			"ingresses.public.certManager.enabled":           "true",
+			"ingresses.public.certManager.createCertificate": "true",
+			"ingresses.public.certManager.clusterIssuer":     "letsencrypt",
+		}),
+	)
+	require.Equal(t, 3, len(certificates))
+
+	// The Certificates of the main Ingress follow its TLS entries, while the additional Ingress uses a generated one
+	expectedDNSNames := map[string][]interface{}{
+		"chart-example-tls":              {"chart-example.local"},
+		"www-chart-example-tls":          {"www.chart-example.local"},
+		"certificates-linter-public-tls": {"chart-example.com"},
+	}
+	for name, dnsNames := range expectedDNSNames {
+		require.Contains(t, certificates, name)
+		spec := certificates[name]["spec"].(map[string]interface{})
+		assert.Equal(t, name, spec["secretName"])
+		assert.Equal(t, dnsNames, spec["dnsNames"])
+		assert.Equal(t, "ClusterIssuer", spec["issuerRef"].(map[string]interface{})["kind"])
+	}
+}
+
+// Test that cert-manager requires exactly one of issuer and clusterIssuer
+func TestK8SServiceCertManagerRequiresExactlyOneIssuer(t *testing.T) {
+	t.Parallel()
+
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name      string
+		setValues map[string]string
+	}{
+		{"none", map[string]string{}},
+		{"both", map[string]string{"ingress.certManager.issuer": "selfsigned", "ingress.certManager.clusterIssuer": "letsencrypt"}},
+	}
+
+	for _, testCase := range testCases {
+		// Capture range variable to force scope
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			setValues := certManagerValues(testCase.setValues)
+			setValues["ingress.certManager.enabled"] = "true"
+
+			// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values
+			// defined.
+			options := &helm.Options{
+				ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+				SetValues:   setValues,
+			}
+			_, err := helm.RenderTemplateE(t, options, helmChartPath, "ingress", []string{"templates/ingress.yaml"})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "exactly one of issuer or clusterIssuer")
+		})
+	}
+}
@@ -121,6 +121,105 @@ func TestK8SServiceAdditionalEnvVarAddsEnvVarsToPod(t *testing.T) {
 	assert.Equal(t, renderedEnvVar["DD_ENTITY_ID"], "metadata.uid")
 }
 
+// Test that setting the structured form of the `envVars` input value exposes the Pod fields and container resources
+// using the downward API, alongside the literal values.
+// We test by injecting to the envVars:
+// DB_HOST: "mysql.default.svc.cluster.local"
+// POD_NAME:
+//   fieldRef: metadata.name
+// MEMORY_LIMIT_MB:
+//   resourceFieldRef: limits.memory
+//   divisor: 1Mi
+func TestK8SServiceEnvVarWithFieldRefsAddsValueFromToPod(t *testing.T) {
+	t.Parallel()
+
+	deployment := renderK8SServiceDeploymentWithSetValues(
+		t,
+		map[string]string{
+			"envVars.DB_HOST":                          "mysql.default.svc.cluster.local",
+			"envVars.POD_NAME.fieldRef":                "metadata.name",
+			"envVars.MEMORY_LIMIT_MB.resourceFieldRef": "limits.memory",
+			"envVars.MEMORY_LIMIT_MB.divisor":          "1Mi",
+		},
+	)
+
+	// Verify that there is only one container and that the environments section is populated.
+	renderedPodContainers := deployment.Spec.Template.Spec.Containers
+	require.Equal(t, len(renderedPodContainers), 1)
+	appContainer := renderedPodContainers[0]
+	environments := appContainer.Env
+	require.Equal(t, len(environments), 3)
+
+	renderedEnvVar := map[string]corev1.EnvVar{}
+	for _, env := range environments {
+		renderedEnvVar[env.Name] = env
+	}
+	assert.Equal(t, renderedEnvVar["DB_HOST"].Value, "mysql.default.svc.cluster.local")
+	assert.Nil(t, renderedEnvVar["DB_HOST"].ValueFrom)
+
+	podNameSource := renderedEnvVar["POD_NAME"].ValueFrom
+	require.NotNil(t, podNameSource)
+	require.NotNil(t, podNameSource.FieldRef)
+	assert.Equal(t, podNameSource.FieldRef.FieldPath, "metadata.name")
+	assert.Nil(t, podNameSource.ResourceFieldRef)
+
+	memoryLimitSource := renderedEnvVar["MEMORY_LIMIT_MB"].ValueFrom
+	require.NotNil(t, memoryLimitSource)
+	require.NotNil(t, memoryLimitSource.ResourceFieldRef)
+	assert.Equal(t, memoryLimitSource.ResourceFieldRef.Resource, "limits.memory")
+	assert.Equal(t, memoryLimitSource.ResourceFieldRef.Divisor.String(), "1Mi")
+	assert.Nil(t, memoryLimitSource.FieldRef)
+}
+
+// Test that an `envVars` entry in the structured form without fieldRef or resourceFieldRef fails to render.
+func TestK8SServiceEnvVarWithoutFieldRefFails(t *testing.T) {
+	t.Parallel()
+
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+		SetValues:   map[string]string{"envVars.MEMORY_LIMIT_MB.divisor": "1Mi"},
+	}
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "deployment", []string{"templates/deployment.yaml"})
+	require.Error(t, err)
+}
+
+// Test that setting `downwardEnv` to true adds the Pod identity environment variables, and that entries of `envVars`
+// with the same name take precedence over the preset.
+func TestK8SServiceDownwardEnvAddsPodIdentityEnvVarsToPod(t *testing.T) {
+	t.Parallel()
+
+	deployment := renderK8SServiceDeploymentWithSetValues(
+		t,
+		map[string]string{
+			"downwardEnv":             "true",
+			"envVars.NODE_NAME":       "overridden",
+			"envVars.POD_IP.fieldRef": "status.hostIP",
+			"envVars.DB_HOST":         "mysql.default.svc.cluster.local",
+		},
+	)
+
+	// Verify that there is only one container and that the environments section is populated.
+	renderedPodContainers := deployment.Spec.Template.Spec.Containers
+	require.Equal(t, len(renderedPodContainers), 1)
+	appContainer := renderedPodContainers[0]
+	environments := appContainer.Env
+	require.Equal(t, len(environments), 5)
+
+	renderedEnvVar := map[string]corev1.EnvVar{}
+	for _, env := range environments {
+		renderedEnvVar[env.Name] = env
+	}
+	assert.Equal(t, renderedEnvVar["POD_NAME"].ValueFrom, &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.name"}})
+	assert.Equal(t, renderedEnvVar["POD_NAMESPACE"].ValueFrom, &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{FieldPath: "metadata.namespace"}})
+	assert.Equal(t, renderedEnvVar["POD_IP"].ValueFrom, &corev1.EnvVarSource{FieldRef: &corev1.ObjectFieldSelector{FieldPath: "status.hostIP"}})
+	assert.Equal(t, renderedEnvVar["NODE_NAME"].Value, "overridden")
+	assert.Nil(t, renderedEnvVar["NODE_NAME"].ValueFrom)
+	assert.Equal(t, renderedEnvVar["DB_HOST"].Value, "mysql.default.svc.cluster.local")
+}
+
 // Test that setting the `configMaps` input value with environment include those environment vars
 // We test by injecting to configMaps:
 // configMaps:
@@ -749,3 +848,111 @@ func checkFileMode(t *testing.T, configMapsOrSecrets string, fileModeOctal strin
 		t.Fatalf("Unexpected attribute name: %s", configMapsOrSecrets)
 	}
 }
+
+// Test that setting `prefix` and `optional` on the envFrom `configMaps` and `secrets` entries sets the Prefix and
+// Optional attributes of the EnvFromSource, and that they are left unset by default.
+func TestK8SServiceEnvFromWithPrefixAndOptional(t *testing.T) {
+	t.Parallel()
+
+	deployment := renderK8SServiceDeploymentWithSetValues(
+		t,
+		map[string]string{
+			"configMaps.appconfig.as":       "envFrom",
+			"configMaps.appconfig.prefix":   "APP_",
+			"configMaps.appconfig.optional": "true",
+			"configMaps.plainconfig.as":     "envFrom",
+			"secrets.devsecret.as":          "envFrom",
+			"secrets.devsecret.prefix":      "DEV_",
+			"secrets.devsecret.optional":    "true",
+		},
+	)
+
+	renderedPodContainers := deployment.Spec.Template.Spec.Containers
+	require.Equal(t, len(renderedPodContainers), 1)
+	envFrom := renderedPodContainers[0].EnvFrom
+	require.Equal(t, len(envFrom), 3)
+
+	// ConfigMaps are rendered before Secrets, each in the order of the keys.
+	appConfig := envFrom[0]
+	require.NotNil(t, appConfig.ConfigMapRef)
+	assert.Equal(t, appConfig.ConfigMapRef.Name, "appconfig")
+	assert.Equal(t, appConfig.Prefix, "APP_")
+	require.NotNil(t, appConfig.ConfigMapRef.Optional)
+	assert.True(t, *appConfig.ConfigMapRef.Optional)
+
+	plainConfig := envFrom[1]
+	require.NotNil(t, plainConfig.ConfigMapRef)
+	assert.Equal(t, plainConfig.ConfigMapRef.Name, "plainconfig")
+	assert.Equal(t, plainConfig.Prefix, "")
+	assert.Nil(t, plainConfig.ConfigMapRef.Optional)
+
+	devSecret := envFrom[2]
+	require.NotNil(t, devSecret.SecretRef)
+	assert.Equal(t, devSecret.SecretRef.Name, "devsecret")
+	assert.Equal(t, devSecret.Prefix, "DEV_")
+	require.NotNil(t, devSecret.SecretRef.Optional)
+	assert.True(t, *devSecret.SecretRef.Optional)
+}
+
+// Test that setting `optional` on the environment `configMaps` and `secrets` entries marks each of the key references
+// as optional, and that the items can override it.
+func TestK8SServiceEnvironmentWithOptionalItems(t *testing.T) {
+	t.Parallel()
+
+	deployment := renderK8SServiceDeploymentWithSetValues(
+		t,
+		map[string]string{
+			"configMaps.dbsettings.as":                     "environment",
+			"configMaps.dbsettings.items.host.envVarName":  "DB_HOST",
+			"configMaps.dbsettings.items.port.envVarName":  "DB_PORT",
+			"configMaps.dbsettings.items.port.optional":    "true",
+			"secrets.dbpassword.as":                        "environment",
+			"secrets.dbpassword.optional":                  "true",
+			"secrets.dbpassword.items.password.envVarName": "DB_PASSWORD",
+			"secrets.dbpassword.items.rootpass.envVarName": "DB_ROOT_PASSWORD",
+			"secrets.dbpassword.items.rootpass.optional":   "false",
+		},
+	)
+
+	renderedPodContainers := deployment.Spec.Template.Spec.Containers
+	require.Equal(t, len(renderedPodContainers), 1)
+	environments := renderedPodContainers[0].Env
+	require.Equal(t, len(environments), 4)
+
+	renderedEnvVar := map[string]corev1.EnvVar{}
+	for _, env := range environments {
+		renderedEnvVar[env.Name] = env
+	}
+	assert.Nil(t, renderedEnvVar["DB_HOST"].ValueFrom.ConfigMapKeyRef.Optional)
+	require.NotNil(t, renderedEnvVar["DB_PORT"].ValueFrom.ConfigMapKeyRef.Optional)
+	assert.True(t, *renderedEnvVar["DB_PORT"].ValueFrom.ConfigMapKeyRef.Optional)
+	require.NotNil(t, renderedEnvVar["DB_PASSWORD"].ValueFrom.SecretKeyRef.Optional)
+	assert.True(t, *renderedEnvVar["DB_PASSWORD"].ValueFrom.SecretKeyRef.Optional)
+	assert.Nil(t, renderedEnvVar["DB_ROOT_PASSWORD"].ValueFrom.SecretKeyRef.Optional)
+}
+
+// Test that setting `optional` on the volume `configMaps` and `secrets` entries marks the volumes as optional.
+func TestK8SServiceVolumeWithOptional(t *testing.T) {
+	t.Parallel()
+
+	deployment := renderK8SServiceDeploymentWithSetValues(
+		t,
+		map[string]string{
+			"configMaps.dbsettings.as":        "volume",
+			"configMaps.dbsettings.mountPath": "/etc/db",
+			"configMaps.dbsettings.optional":  "true",
+			"secrets.dbpassword.as":           "volume",
+			"secrets.dbpassword.mountPath":    "/etc/dbpass",
+			"secrets.dbpassword.optional":     "true",
+		},
+	)
+
+	renderedPodVolumes := deployment.Spec.Template.Spec.Volumes
+	require.Equal(t, len(renderedPodVolumes), 2)
+	require.NotNil(t, renderedPodVolumes[0].ConfigMap)
+	require.NotNil(t, renderedPodVolumes[0].ConfigMap.Optional)
+	assert.True(t, *renderedPodVolumes[0].ConfigMap.Optional)
+	require.NotNil(t, renderedPodVolumes[1].Secret)
+	require.NotNil(t, renderedPodVolumes[1].Secret.Optional)
+	assert.True(t, *renderedPodVolumes[1].Secret.Optional)
+}
@@ -0,0 +1,192 @@
+//go:build all || tpl
+// +build all tpl
+
+// NOTE: We use build flags to differentiate between template tests and integration tests so that you can conveniently
+// run just the template tests. See the test README for more information.
+
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/helm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Test that configMaps entries that only reference an existing ConfigMap do not render a ConfigMap resource.
+func TestK8SServiceConfigMapsWithoutDataDoesNotRender(t *testing.T) {
+	t.Parallel()
+
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+		SetValues: map[string]string{
+			"configMaps.dbsettings.as":        "volume",
+			"configMaps.dbsettings.mountPath": "/etc/db",
+		},
+	}
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "configmaps", []string{"templates/configmaps.yaml"})
+	require.Error(t, err)
+}
+
+// Test that setting data and binaryData on a configMaps entry renders a ConfigMap named after the key.
+func TestK8SServiceConfigMapsWithDataRendersConfigMap(t *testing.T) {
+	t.Parallel()
+
+	configMaps := renderK8SServiceConfigMapsWithSetValues(
+		t,
+		map[string]string{
+			"configMaps.dbsettings.as":                    "environment",
+			"configMaps.dbsettings.items.host.envVarName": "DB_HOST",
+			"configMaps.dbsettings.data.host":             "mysql.default.svc.cluster.local",
+			"configMaps.dbsettings.data.port":             "3306",
+			"configMaps.dbsettings.binaryData.cert":       "aGVsbG8=",
+		},
+	)
+	require.Equal(t, len(configMaps), 1)
+	configMap := configMaps[0]
+	assert.Equal(t, configMap.Name, "dbsettings")
+	assert.Equal(t, configMap.Data, map[string]string{
+		"host": "mysql.default.svc.cluster.local",
+		"port": "3306",
+	})
+	assert.Equal(t, configMap.BinaryData, map[string][]byte{"cert": []byte("hello")})
+}
+
+// Test that setting files on a configMaps entry loads the matching chart files into the ConfigMap data, keyed by the
+// base name of the file.
+func TestK8SServiceConfigMapsWithFilesRendersConfigMap(t *testing.T) {
+	t.Parallel()
+
+	configMaps := renderK8SServiceConfigMapsWithSetValues(
+		t,
+		map[string]string{
+			"configMaps.lintersettings.as":        "volume",
+			"configMaps.lintersettings.mountPath": "/etc/linter",
+			"configMaps.lintersettings.files[0]":  "linter_*.yaml",
+			"configMaps.lintersettings.data.foo":  "bar",
+		},
+	)
+	require.Equal(t, len(configMaps), 1)
+	configMap := configMaps[0]
+	assert.Equal(t, configMap.Name, "lintersettings")
+
+	expectedContents, err := os.ReadFile(filepath.Join("..", "charts", "k8s-service", "linter_values.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, len(configMap.Data), 2)
+	assert.Equal(t, configMap.Data["foo"], "bar")
+	assert.Equal(t, strings.TrimSpace(configMap.Data["linter_values.yaml"]), strings.TrimSpace(string(expectedContents)))
+}
+
+// Test that the generated ConfigMaps are still wired into the Pod using the existing injection settings.
+func TestK8SServiceConfigMapsWithDataKeepsInjectionWiring(t *testing.T) {
+	t.Parallel()
+
+	setValues := map[string]string{
+		"configMaps.dbsettings.as":                    "environment",
+		"configMaps.dbsettings.items.host.envVarName": "DB_HOST",
+		"configMaps.dbsettings.data.host":             "mysql.default.svc.cluster.local",
+		"configMaps.appsettings.as":                   "volume",
+		"configMaps.appsettings.mountPath":            "/etc/app",
+		"configMaps.appsettings.data.foo":             "bar",
+		"configMaps.flags.as":                         "envFrom",
+		"configMaps.flags.data.FEATURE_X":             "true",
+	}
+	configMaps := renderK8SServiceConfigMapsWithSetValues(t, setValues)
+	require.Equal(t, len(configMaps), 3)
+
+	deployment := renderK8SServiceDeploymentWithSetValues(t, setValues)
+	renderedPodSpec := deployment.Spec.Template.Spec
+	require.Equal(t, len(renderedPodSpec.Containers), 1)
+	appContainer := renderedPodSpec.Containers[0]
+
+	require.Equal(t, len(appContainer.Env), 1)
+	assert.Equal(t, appContainer.Env[0].Name, "DB_HOST")
+	assert.Equal(t, appContainer.Env[0].ValueFrom.ConfigMapKeyRef.Name, "dbsettings")
+	assert.Equal(t, appContainer.Env[0].ValueFrom.ConfigMapKeyRef.Key, "host")
+
+	require.Equal(t, len(appContainer.EnvFrom), 1)
+	assert.Equal(t, appContainer.EnvFrom[0].ConfigMapRef.Name, "flags")
+
+	require.Equal(t, len(renderedPodSpec.Volumes), 1)
+	assert.Equal(t, renderedPodSpec.Volumes[0].Name, "appsettings-volume")
+	assert.Equal(t, renderedPodSpec.Volumes[0].ConfigMap.Name, "appsettings")
+	require.Equal(t, len(appContainer.VolumeMounts), 1)
+	assert.Equal(t, appContainer.VolumeMounts[0].MountPath, "/etc/app")
+}
+
+// Test that configChecksumAnnotations is disabled by default, so no checksum annotations are added to the Pods.
+func TestK8SServiceConfigChecksumAnnotationsDisabledByDefault(t *testing.T) {
+	t.Parallel()
+
+	deployment := renderK8SServiceDeploymentWithSetValues(
+		t,
+		map[string]string{
+			"configMaps.dbsettings.as":        "volume",
+			"configMaps.dbsettings.mountPath": "/etc/db",
+			"configMaps.dbsettings.data.host": "mysql.default.svc.cluster.local",
+		},
+	)
+	assert.Equal(t, len(deployment.Spec.Template.Annotations), 0)
+}
+
+// Test that the checksum annotation of a ConfigMap created by the chart only changes when the ConfigMap contents change,
+// for both the main and canary Pods.
+func TestK8SServiceConfigChecksumAnnotationsTrackConfigMapData(t *testing.T) {
+	t.Parallel()
+
+	baseValues := map[string]string{
+		"configChecksumAnnotations":         "true",
+		"podAnnotations.foo":                "bar",
+		"canary.enabled":                    "true",
+		"canary.containerImage.repository":  "nginx",
+		"canary.containerImage.tag":         "1.16.0",
+		"configMaps.dbsettings.as":          "volume",
+		"configMaps.dbsettings.mountPath":   "/etc/db",
+		"configMaps.dbsettings.data.host":   "mysql.default.svc.cluster.local",
+		"configMaps.appsettings.as":         "envFrom",
+		"configMaps.appsettings.data.DEBUG": "false",
+		"configMaps.external.as":            "envFrom",
+	}
+	withValues := func(overrides map[string]string) map[string]string {
+		values := map[string]string{}
+		for key, value := range baseValues {
+			values[key] = value
+		}
+		for key, value := range overrides {
+			values[key] = value
+		}
+		return values
+	}
+
+	deployment := renderK8SServiceDeploymentWithSetValues(t, baseValues)
+	annotations := deployment.Spec.Template.Annotations
+	require.Equal(t, len(annotations), 3)
+	assert.Equal(t, annotations["foo"], "bar")
+	assert.Len(t, annotations["checksum/configmap-dbsettings"], 64)
+	assert.Len(t, annotations["checksum/configmap-appsettings"], 64)
+
+	canaryDeployment := renderK8SServiceCanaryDeploymentWithSetValues(t, baseValues)
+	assert.Equal(t, canaryDeployment.Spec.Template.Annotations, annotations)
+
+	// Rendering with the same values yields the same checksums.
+	rerenderedDeployment := renderK8SServiceDeploymentWithSetValues(t, withValues(map[string]string{"podAnnotations.foo": "baz"}))
+	rerenderedAnnotations := rerenderedDeployment.Spec.Template.Annotations
+	assert.Equal(t, rerenderedAnnotations["checksum/configmap-dbsettings"], annotations["checksum/configmap-dbsettings"])
+	assert.Equal(t, rerenderedAnnotations["checksum/configmap-appsettings"], annotations["checksum/configmap-appsettings"])
+
+	// Changing the data of one ConfigMap only changes the checksum of that ConfigMap.
+	updatedDeployment := renderK8SServiceDeploymentWithSetValues(t, withValues(map[string]string{"configMaps.dbsettings.data.host": "postgres.default.svc.cluster.local"}))
+	updatedAnnotations := updatedDeployment.Spec.Template.Annotations
+	assert.NotEqual(t, updatedAnnotations["checksum/configmap-dbsettings"], annotations["checksum/configmap-dbsettings"])
+	assert.Equal(t, updatedAnnotations["checksum/configmap-appsettings"], annotations["checksum/configmap-appsettings"])
+
+	updatedCanaryDeployment := renderK8SServiceCanaryDeploymentWithSetValues(t, withValues(map[string]string{"configMaps.dbsettings.data.host": "postgres.default.svc.cluster.local"}))
+	assert.Equal(t, updatedCanaryDeployment.Spec.Template.Annotations, updatedAnnotations)
+}
@@ -0,0 +1,131 @@
+//go:build all || tpl
+// +build all tpl
+
+// NOTE: We use build flags to differentiate between template tests and integration tests so that you can conveniently
+// run just the template tests. See the test README for more information.
+
+package test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/helm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Test that the default workloadType does not render the DaemonSet
+func TestK8SServiceDaemonSetNotRenderedByDefault(t *testing.T) {
+	t.Parallel()
+
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values defined.
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+	}
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "daemonset", []string{"templates/daemonset.yaml"})
+	require.Error(t, err)
+}
+
+// Test that the DaemonSet reuses the pod template of the Deployment and sets the update strategy
+func TestK8SServiceDaemonSetRendersControllerSettings(t *testing.T) {
+	t.Parallel()
+
+	daemonset := renderK8SServiceDaemonSetWithSetValues(
+		t,
+		map[string]string{
+			"workloadType":    "DaemonSet",
+			"envVars.DB_HOST": "mysql.default.svc.cluster.local",
+			"daemonSet.updateStrategy.rollingUpdate.maxUnavailable": "10%",
+			"daemonSet.updateStrategy.rollingUpdate.maxSurge":       "0",
+		},
+	)
+
+	assert.Equal(t, appsv1.RollingUpdateDaemonSetStrategyType, daemonset.Spec.UpdateStrategy.Type)
+	require.NotNil(t, daemonset.Spec.UpdateStrategy.RollingUpdate)
+	assert.Equal(t, "10%", daemonset.Spec.UpdateStrategy.RollingUpdate.MaxUnavailable.String())
+	assert.Equal(t, 0, daemonset.Spec.UpdateStrategy.RollingUpdate.MaxSurge.IntValue())
+
+	// Verify that the container spec is the same as the one rendered for the Deployment
+	renderedPodContainers := daemonset.Spec.Template.Spec.Containers
+	require.Equal(t, len(renderedPodContainers), 1)
+	appContainer := renderedPodContainers[0]
+	assert.Equal(t, "nginx:stable", appContainer.Image)
+	require.Equal(t, len(appContainer.Env), 1)
+	assert.Equal(t, "DB_HOST", appContainer.Env[0].Name)
+}
+
+// Test that replicaCount and horizontalPodAutoscaler are ignored for DaemonSets
+func TestK8SServiceDaemonSetIgnoresReplicaCountAndHPA(t *testing.T) {
+	t.Parallel()
+
+	setValues := map[string]string{
+		"workloadType":                    "DaemonSet",
+		"replicaCount":                    "3",
+		"horizontalPodAutoscaler.enabled": "true",
+	}
+	daemonset := renderK8SServiceDaemonSetWithSetValues(t, setValues)
+	assert.Equal(t, "DaemonSet", daemonset.Kind)
+
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values defined.
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+		SetValues:   setValues,
+	}
+	out := helm.RenderTemplate(t, options, helmChartPath, "daemonset", []string{"templates/daemonset.yaml"})
+	assert.NotContains(t, out, "replicas:")
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "hpa", []string{"templates/horizontalpodautoscaler.yaml"})
+	require.Error(t, err)
+}
+
+// Test that host level settings are rendered into the pod spec
+func TestK8SServiceDaemonSetHostSettings(t *testing.T) {
+	t.Parallel()
+
+	daemonset := renderK8SServiceDaemonSetWithSetValues(
+		t,
+		map[string]string{
+			"workloadType":                            "DaemonSet",
+			"hostNetwork":                             "true",
+			"hostPID":                                 "true",
+			"dnsPolicy":                               "ClusterFirstWithHostNet",
+			"hostPathVolumes.varlog.path":             "/var/log",
+			"hostPathVolumes.varlog.mountPath":        "/host/var/log",
+			"hostPathVolumes.varlog.type":             "Directory",
+			"hostPathVolumes.varlog.readOnly":         "true",
+			"hostPathVolumes.varlog.mountPropagation": "HostToContainer",
+		},
+	)
+
+	podSpec := daemonset.Spec.Template.Spec
+	assert.True(t, podSpec.HostNetwork)
+	assert.True(t, podSpec.HostPID)
+	assert.Equal(t, corev1.DNSClusterFirstWithHostNet, podSpec.DNSPolicy)
+
+	// Verify that a mount has been created for the host path
+	renderedPodContainers := podSpec.Containers
+	require.Equal(t, len(renderedPodContainers), 1)
+	mounts := renderedPodContainers[0].VolumeMounts
+	require.Equal(t, len(mounts), 1)
+	assert.Equal(t, "varlog", mounts[0].Name)
+	assert.Equal(t, "/host/var/log", mounts[0].MountPath)
+	assert.True(t, mounts[0].ReadOnly)
+	require.NotNil(t, mounts[0].MountPropagation)
+	assert.Equal(t, corev1.MountPropagationHostToContainer, *mounts[0].MountPropagation)
+
+	// Verify that a volume has been declared for the host path
+	volumes := podSpec.Volumes
+	require.Equal(t, len(volumes), 1)
+	assert.Equal(t, "varlog", volumes[0].Name)
+	require.NotNil(t, volumes[0].HostPath)
+	assert.Equal(t, "/var/log", volumes[0].HostPath.Path)
+	assert.Equal(t, corev1.HostPathDirectory, *volumes[0].HostPath.Type)
+}
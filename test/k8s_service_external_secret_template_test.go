@@ -0,0 +1,187 @@
+//go:build all || tpl
+// +build all tpl
+
+// NOTE: We use build flags to differentiate between template tests and integration tests so that you can conveniently
+// run just the template tests. See the test README for more information.
+
+package test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"github.com/gruntwork-io/terratest/modules/helm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// renderK8SServiceExternalSecretsWithSetValues renders the ExternalSecret resources and returns them keyed by name as
+// maps, since there are no Go types available for the External Secrets Operator resources in this module.
+func renderK8SServiceExternalSecretsWithSetValues(t *testing.T, setValues map[string]string) map[string]map[string]interface{} {
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values defined.
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+		SetValues:   setValues,
+	}
+	out := helm.RenderTemplate(t, options, helmChartPath, "externalsecrets", []string{"templates/externalsecrets.yaml"})
+
+	externalSecrets := map[string]map[string]interface{}{}
+	for _, document := range splitYamlDocuments(out) {
+		rendered := map[string]interface{}{}
+		require.NoError(t, yaml.Unmarshal([]byte(document), &rendered))
+		assert.Equal(t, "external-secrets.io/v1beta1", rendered["apiVersion"])
+		assert.Equal(t, "ExternalSecret", rendered["kind"])
+		name := rendered["metadata"].(map[string]interface{})["name"].(string)
+		externalSecrets[name] = rendered
+	}
+	return externalSecrets
+}
+
+// Test that no ExternalSecret is rendered by default
+func TestK8SServiceExternalSecretsNotRenderedByDefault(t *testing.T) {
+	t.Parallel()
+
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values defined.
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+	}
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "externalsecrets", []string{"templates/externalsecrets.yaml"})
+	require.Error(t, err)
+}
+
+// Test that the data map of externalSecrets is converted to the list of key mappings of the ExternalSecret spec, and that
+// the target Secret is always named after the key.
+func TestK8SServiceExternalSecretsMapsDataKeys(t *testing.T) {
+	t.Parallel()
+
+	externalSecrets := renderK8SServiceExternalSecretsWithSetValues(
+		t,
+		map[string]string{
+			"externalSecrets.dbcreds.secretStoreRef.name":         "aws-secrets-manager",
+			"externalSecrets.dbcreds.secretStoreRef.kind":         "ClusterSecretStore",
+			"externalSecrets.dbcreds.refreshInterval":             "1h",
+			"externalSecrets.dbcreds.target.name":                 "overridden",
+			"externalSecrets.dbcreds.target.creationPolicy":       "Owner",
+			"externalSecrets.dbcreds.data.password.key":           "prod/db",
+			"externalSecrets.dbcreds.data.password.property":      "password",
+			"externalSecrets.dbcreds.data.username.key":           "prod/db",
+			"externalSecrets.dbcreds.data.username.property":      "username",
+			"externalSecrets.apikeys.secretStoreRef.name":         "aws-secrets-manager",
+			"externalSecrets.apikeys.dataFrom[0].extract.key":     "prod/apikeys",
+			"externalSecrets.apikeys.dataFrom[0].extract.version": "AWSCURRENT",
+		},
+	)
+	require.Equal(t, len(externalSecrets), 2)
+
+	dbcredsSpec := externalSecrets["dbcreds"]["spec"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"name": "aws-secrets-manager", "kind": "ClusterSecretStore"}, dbcredsSpec["secretStoreRef"])
+	assert.Equal(t, "1h", dbcredsSpec["refreshInterval"])
+	assert.Equal(t, map[string]interface{}{"name": "dbcreds", "creationPolicy": "Owner"}, dbcredsSpec["target"])
+	assert.Equal(
+		t,
+		[]interface{}{
+			map[string]interface{}{
+				"secretKey": "password",
+				"remoteRef": map[string]interface{}{"key": "prod/db", "property": "password"},
+			},
+			map[string]interface{}{
+				"secretKey": "username",
+				"remoteRef": map[string]interface{}{"key": "prod/db", "property": "username"},
+			},
+		},
+		dbcredsSpec["data"],
+	)
+	assert.NotContains(t, dbcredsSpec, "dataFrom")
+
+	apikeysSpec := externalSecrets["apikeys"]["spec"].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"name": "apikeys"}, apikeysSpec["target"])
+	assert.NotContains(t, apikeysSpec, "refreshInterval")
+	assert.NotContains(t, apikeysSpec, "data")
+	assert.Equal(
+		t,
+		[]interface{}{
+			map[string]interface{}{
+				"extract": map[string]interface{}{"key": "prod/apikeys", "version": "AWSCURRENT"},
+			},
+		},
+		apikeysSpec["dataFrom"],
+	)
+}
+
+// Test that the ExternalSecret fails to render without a secretStoreRef or without any data mappings.
+func TestK8SServiceExternalSecretsRequiresStoreAndData(t *testing.T) {
+	t.Parallel()
+
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name      string
+		setValues map[string]string
+	}{
+		{
+			"missing secretStoreRef",
+			map[string]string{"externalSecrets.dbcreds.data.password.key": "prod/db"},
+		},
+		{
+			"missing data",
+			map[string]string{"externalSecrets.dbcreds.secretStoreRef.name": "aws-secrets-manager"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		// Capture range variable to force scope
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			options := &helm.Options{
+				ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+				SetValues:   testCase.setValues,
+			}
+			_, err := helm.RenderTemplateE(t, options, helmChartPath, "externalsecrets", []string{"templates/externalsecrets.yaml"})
+			require.Error(t, err)
+		})
+	}
+}
+
+// Test that the target Secret of an ExternalSecret can be injected into the Pod with the secrets input value, both as
+// environment variables and as a volume.
+func TestK8SServiceExternalSecretsTargetInjectedWithSecrets(t *testing.T) {
+	t.Parallel()
+
+	deployment := renderK8SServiceDeploymentWithSetValues(
+		t,
+		map[string]string{
+			"externalSecrets.dbcreds.secretStoreRef.name":    "aws-secrets-manager",
+			"externalSecrets.dbcreds.data.password.key":      "prod/db",
+			"externalSecrets.dbcreds.data.password.property": "password",
+			"externalSecrets.tlscert.secretStoreRef.name":    "aws-secrets-manager",
+			"externalSecrets.tlscert.data.tls\\.crt.key":     "prod/tls",
+			"secrets.dbcreds.as":                             "environment",
+			"secrets.dbcreds.items.password.envVarName":      "DB_PASSWORD",
+			"secrets.tlscert.as":                             "volume",
+			"secrets.tlscert.mountPath":                      "/etc/tls",
+		},
+	)
+	renderedPodSpec := deployment.Spec.Template.Spec
+	appContainer := renderedPodSpec.Containers[0]
+
+	require.Equal(t, len(appContainer.Env), 1)
+	assert.Equal(t, appContainer.Env[0].Name, "DB_PASSWORD")
+	assert.Equal(t, appContainer.Env[0].ValueFrom.SecretKeyRef.Name, "dbcreds")
+	assert.Equal(t, appContainer.Env[0].ValueFrom.SecretKeyRef.Key, "password")
+
+	require.Equal(t, len(renderedPodSpec.Volumes), 1)
+	assert.Equal(t, renderedPodSpec.Volumes[0].Name, "tlscert-volume")
+	assert.Equal(t, renderedPodSpec.Volumes[0].Secret.SecretName, "tlscert")
+	require.Equal(t, len(appContainer.VolumeMounts), 1)
+	assert.Equal(t, appContainer.VolumeMounts[0].MountPath, "/etc/tls")
+}
@@ -0,0 +1,296 @@
+//go:build all || tpl
+// +build all tpl
+
+// NOTE: We use build flags to differentiate between template tests and integration tests so that you can conveniently
+// run just the template tests. See the test README for more information.
+
+package test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"github.com/gruntwork-io/terratest/modules/helm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// renderK8SServiceFlaggerCanarySpecWithSetValues renders the Flagger Canary resource and returns its spec as a map, since
+// there are no Go types available for the Flagger resources in this module.
+func renderK8SServiceFlaggerCanarySpecWithSetValues(t *testing.T, setValues map[string]string) map[string]interface{} {
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values defined.
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+		SetValues:   setValues,
+	}
+	out := helm.RenderTemplate(t, options, helmChartPath, "flagger", []string{"templates/flagger.yaml"})
+
+	rendered := map[string]interface{}{}
+	require.NoError(t, yaml.Unmarshal([]byte(out), &rendered))
+	assert.Equal(t, "flagger.app/v1beta1", rendered["apiVersion"])
+	assert.Equal(t, "Canary", rendered["kind"])
+	return rendered["spec"].(map[string]interface{})
+}
+
+// Test that the Flagger Canary is not rendered by default
+func TestK8SServiceFlaggerNotRenderedByDefault(t *testing.T) {
+	t.Parallel()
+
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values defined.
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+	}
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "flagger", []string{"templates/flagger.yaml"})
+	require.Error(t, err)
+}
+
+// Test that the Flagger Canary targets the main Deployment and renders the analysis settings, without any references
+// to the Ingress or Horizontal Pod Autoscaler when they are disabled.
+func TestK8SServiceFlaggerCanaryTargetsDeployment(t *testing.T) {
+	t.Parallel()
+
+	spec := renderK8SServiceFlaggerCanarySpecWithSetValues(
+		t,
+		map[string]string{
+			"flagger.enabled":                      "true",
+			"flagger.analysis.interval":            "30s",
+			"flagger.analysis.threshold":           "3",
+			"flagger.analysis.maxWeight":           "60",
+			"flagger.analysis.stepWeight":          "20",
+			"flagger.analysis.metrics[0].name":     "request-success-rate",
+			"flagger.analysis.metrics[0].interval": "1m",
+		},
+	)
+
+	targetRef := spec["targetRef"].(map[string]interface{})
+	assert.Equal(t, "apps/v1", targetRef["apiVersion"])
+	assert.Equal(t, "Deployment", targetRef["kind"])
+	assert.Equal(t, "flagger-linter", targetRef["name"])
+
+	_, hasIngressRef := spec["ingressRef"]
+	assert.False(t, hasIngressRef)
+	_, hasAutoscalerRef := spec["autoscalerRef"]
+	assert.False(t, hasAutoscalerRef)
+	_, hasProvider := spec["provider"]
+	assert.False(t, hasProvider)
+
+	service := spec["service"].(map[string]interface{})
+	assert.Equal(t, float64(80), service["port"])
+	assert.Equal(t, "http", service["targetPort"])
+
+	analysis := spec["analysis"].(map[string]interface{})
+	assert.Equal(t, "30s", analysis["interval"])
+	assert.Equal(t, float64(3), analysis["threshold"])
+	assert.Equal(t, float64(60), analysis["maxWeight"])
+	assert.Equal(t, float64(20), analysis["stepWeight"])
+	metrics := analysis["metrics"].([]interface{})
+	require.Equal(t, len(metrics), 1)
+	assert.Equal(t, "request-success-rate", metrics[0].(map[string]interface{})["name"])
+}
+
+// Test that the Flagger Canary references the Ingress and Horizontal Pod Autoscaler of the chart when they are enabled
+func TestK8SServiceFlaggerCanaryReferencesIngressAndAutoscaler(t *testing.T) {
+	t.Parallel()
+
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	setValues := map[string]string{
+		"kubeVersionOverride":             "1.25.0",
+		"flagger.enabled":                 "true",
+		"ingress.enabled":                 "true",
+		"ingress.path":                    "/app",
+		"ingress.servicePort":             "app",
+		"horizontalPodAutoscaler.enabled": "true",
+	}
+	spec := renderK8SServiceFlaggerCanarySpecWithSetValues(t, setValues)
+
+	assert.Equal(t, "nginx", spec["provider"])
+
+	ingressRef := spec["ingressRef"].(map[string]interface{})
+	assert.Equal(t, "networking.k8s.io/v1", ingressRef["apiVersion"])
+	assert.Equal(t, "Ingress", ingressRef["kind"])
+	assert.Equal(t, "flagger-linter", ingressRef["name"])
+
+	autoscalerRef := spec["autoscalerRef"].(map[string]interface{})
+	assert.Equal(t, "autoscaling/v2", autoscalerRef["apiVersion"])
+	assert.Equal(t, "HorizontalPodAutoscaler", autoscalerRef["kind"])
+	assert.Equal(t, "flagger-linter", autoscalerRef["name"])
+
+	// Verify that the references match the rendered Ingress and Horizontal Pod Autoscaler of the same release
+	// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values defined.
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+		SetValues:   setValues,
+	}
+	for _, templateAndRef := range []struct {
+		template  string
+		reference map[string]interface{}
+	}{
+		{"templates/ingress.yaml", ingressRef},
+		{"templates/horizontalpodautoscaler.yaml", autoscalerRef},
+	} {
+		out := helm.RenderTemplate(t, options, helmChartPath, "flagger", []string{templateAndRef.template})
+
+		rendered := map[string]interface{}{}
+		require.NoError(t, yaml.Unmarshal([]byte(out), &rendered))
+		assert.Equal(t, templateAndRef.reference["apiVersion"], rendered["apiVersion"])
+		assert.Equal(t, templateAndRef.reference["kind"], rendered["kind"])
+		assert.Equal(t, templateAndRef.reference["name"], rendered["metadata"].(map[string]interface{})["name"])
+	}
+
+	// Verify that the Services generated by Flagger expose the port the Ingress routes to
+	service := spec["service"].(map[string]interface{})
+	assert.Equal(t, "app", service["portName"])
+	assert.Equal(t, float64(80), service["port"])
+	assert.Equal(t, "http", service["targetPort"])
+	ingress := renderK8SServiceIngressWithSetValues(t, setValues)
+	ingressBackend := ingress.Spec.Rules[0].HTTP.Paths[0].Backend.Service
+	assert.Equal(t, service["portName"], ingressBackend.Port.Name)
+}
+
+// Test that the Flagger Canary is rejected when its Service port does not match the port the Ingress routes to
+func TestK8SServiceFlaggerRejectsServicePortNotMatchingIngress(t *testing.T) {
+	t.Parallel()
+
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name      string
+		setValues map[string]string
+	}{
+		{"port name", map[string]string{"flagger.service.portName": "http"}},
+		{"port", map[string]string{"flagger.service.port": "8080"}},
+		{"unknown ingress servicePort", map[string]string{"ingress.servicePort": "grpc"}},
+	}
+
+	for _, testCase := range testCases {
+		// Capture range variable to force scope
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			setValues := map[string]string{
+				"flagger.enabled":     "true",
+				"ingress.enabled":     "true",
+				"ingress.path":        "/app",
+				"ingress.servicePort": "app",
+			}
+			for key, value := range testCase.setValues {
+				setValues[key] = value
+			}
+			// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values defined.
+			options := &helm.Options{
+				ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+				SetValues:   setValues,
+			}
+			_, err := helm.RenderTemplateE(t, options, helmChartPath, "flagger", []string{"templates/flagger.yaml"})
+			require.Error(t, err)
+		})
+	}
+}
+
+// Test that the Service of the chart is not rendered when Flagger is enabled, as Flagger generates it
+func TestK8SServiceFlaggerDisablesChartService(t *testing.T) {
+	t.Parallel()
+
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values defined.
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+		SetValues: map[string]string{
+			"flagger.enabled": "true",
+			"service.enabled": "true",
+		},
+	}
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "flagger", []string{"templates/service.yaml"})
+	require.Error(t, err)
+}
+
+// Test that the annotations of the Service, including the GKE BackendConfig annotation, are added to the Services
+// generated by Flagger, without overriding the annotations that are set directly on them.
+func TestK8SServiceFlaggerCanaryPassesServiceAnnotations(t *testing.T) {
+	t.Parallel()
+
+	spec := renderK8SServiceFlaggerCanarySpecWithSetValues(
+		t,
+		map[string]string{
+			"flagger.enabled":                          "true",
+			"flagger.service.primary.annotations.team": "api",
+			"google.backendConfig.enabled":             "true",
+			"service.annotations.team":                 "web",
+		},
+	)
+
+	service := spec["service"].(map[string]interface{})
+	assert.Equal(t, float64(80), service["port"])
+	for _, serviceType := range []string{"apex", "primary", "canary"} {
+		annotations := service[serviceType].(map[string]interface{})["annotations"].(map[string]interface{})
+		assert.Equal(t, `{"default":"flagger-linter"}`, annotations["cloud.google.com/backend-config"])
+		if serviceType == "primary" {
+			assert.Equal(t, "api", annotations["team"])
+		} else {
+			assert.Equal(t, "web", annotations["team"])
+		}
+	}
+}
+
+// Test that the MetricTemplates are rendered and named after their keys
+func TestK8SServiceFlaggerMetricTemplates(t *testing.T) {
+	t.Parallel()
+
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values defined.
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+		SetValues: map[string]string{
+			"flagger.enabled": "true",
+			"flagger.metricTemplates.error-rate.provider.type":    "prometheus",
+			"flagger.metricTemplates.error-rate.provider.address": "http://prometheus.monitoring:9090",
+			"flagger.metricTemplates.error-rate.query":            "sum(rate(http_requests_total{status=~\"5.*\"}[1m]))",
+		},
+	}
+	out := helm.RenderTemplate(t, options, helmChartPath, "flagger", []string{"templates/flaggermetrictemplates.yaml"})
+
+	rendered := map[string]interface{}{}
+	require.NoError(t, yaml.Unmarshal([]byte(out), &rendered))
+	assert.Equal(t, "MetricTemplate", rendered["kind"])
+	assert.Equal(t, "error-rate", rendered["metadata"].(map[string]interface{})["name"])
+	provider := rendered["spec"].(map[string]interface{})["provider"].(map[string]interface{})
+	assert.Equal(t, "prometheus", provider["type"])
+}
+
+// Test that Flagger can not be combined with the canary Deployment
+func TestK8SServiceFlaggerRejectsCanary(t *testing.T) {
+	t.Parallel()
+
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values defined.
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+		SetValues: map[string]string{
+			"flagger.enabled":                  "true",
+			"canary.enabled":                   "true",
+			"canary.containerImage.repository": "nginx",
+			"canary.containerImage.tag":        "1.17.0",
+		},
+	}
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "flagger", []string{"templates/flagger.yaml"})
+	require.Error(t, err)
+	assert.True(t, strings.Contains(err.Error(), "flagger can not be used together with canary or blueGreen"))
+}
@@ -0,0 +1,337 @@
+//go:build all || tpl
+// +build all tpl
+
+// NOTE: We use build flags to differentiate between template tests and integration tests so that you can conveniently
+// run just the template tests. See the test README for more information.
+
+package test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"github.com/gruntwork-io/terratest/modules/helm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// renderK8SServiceGatewayRoutesWithSetValues renders the Gateway API route resources and returns them keyed by kind as
+// maps, since there are no Go types available for the Gateway API resources in this module.
+func renderK8SServiceGatewayRoutesWithSetValues(t *testing.T, setValues map[string]string) map[string]map[string]interface{} {
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values defined.
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+		SetValues:   setValues,
+	}
+	out := helm.RenderTemplate(t, options, helmChartPath, "gateway", []string{"templates/gateway.yaml"})
+
+	routes := map[string]map[string]interface{}{}
+	for _, document := range splitYamlDocuments(out) {
+		rendered := map[string]interface{}{}
+		require.NoError(t, yaml.Unmarshal([]byte(document), &rendered))
+		routes[rendered["kind"].(string)] = rendered
+	}
+	return routes
+}
+
+// Test that no route is rendered by default
+func TestK8SServiceGatewayRoutesNotRenderedByDefault(t *testing.T) {
+	t.Parallel()
+
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values defined.
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+	}
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "gateway", []string{"templates/gateway.yaml"})
+	require.Error(t, err)
+}
+
+// Test that the gateway requires parentRefs to attach the route to
+func TestK8SServiceGatewayRequiresParentRefs(t *testing.T) {
+	t.Parallel()
+
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values defined.
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+		SetValues:   map[string]string{"gateway.enabled": "true"},
+	}
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "gateway", []string{"templates/gateway.yaml"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "gateway.parentRefs is required")
+}
+
+// Test that the HTTPRoute apiVersion is selected based on the Kubernetes version
+func TestK8SServiceGatewayHTTPRouteAPIVersion(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		kubeVersion            string
+		expectedHTTPAPIVersion string
+		expectedGRPCAPIVersion string
+	}{
+		{"1.25.0", "gateway.networking.k8s.io/v1beta1", "gateway.networking.k8s.io/v1alpha2"},
+		{"1.29.0", "gateway.networking.k8s.io/v1", "gateway.networking.k8s.io/v1"},
+	}
+
+	for _, testCase := range testCases {
+		// Capture range variable to force scope
+		testCase := testCase
+		t.Run(testCase.kubeVersion, func(t *testing.T) {
+			t.Parallel()
+
+			routes := renderK8SServiceGatewayRoutesWithSetValues(
+				t,
+				map[string]string{
+					"kubeVersionOverride":        testCase.kubeVersion,
+					"gateway.enabled":            "true",
+					"gateway.parentRefs[0].name": "public",
+					"gateway.grpc.enabled":       "true",
+					"gateway.grpc.servicePort":   "9090",
+				},
+			)
+			require.Contains(t, routes, "HTTPRoute")
+			require.Contains(t, routes, "GRPCRoute")
+			assert.Equal(t, testCase.expectedHTTPAPIVersion, routes["HTTPRoute"]["apiVersion"])
+			assert.Equal(t, testCase.expectedGRPCAPIVersion, routes["GRPCRoute"]["apiVersion"])
+		})
+	}
+}
+
+// Test that the HTTPRoute renders the parentRefs, hostnames, and a default rule routing all paths to the service
+func TestK8SServiceGatewayHTTPRouteDefaultRule(t *testing.T) {
+	t.Parallel()
+
+	routes := renderK8SServiceGatewayRoutesWithSetValues(
+		t,
+		map[string]string{
+			"gateway.enabled":                   "true",
+			"gateway.parentRefs[0].name":        "public",
+			"gateway.parentRefs[0].namespace":   "gateway",
+			"gateway.parentRefs[0].sectionName": "https",
+			"gateway.hostnames[0]":              "chart-example.local",
+		},
+	)
+	require.Contains(t, routes, "HTTPRoute")
+	assert.NotContains(t, routes, "GRPCRoute")
+	route := routes["HTTPRoute"]
+	assert.Equal(t, "gateway-linter", route["metadata"].(map[string]interface{})["name"])
+
+	spec := route["spec"].(map[string]interface{})
+	assert.Equal(
+		t,
+		[]interface{}{map[string]interface{}{"name": "public", "namespace": "gateway", "sectionName": "https"}},
+		spec["parentRefs"],
+	)
+	assert.Equal(t, []interface{}{"chart-example.local"}, spec["hostnames"])
+	assert.Equal(
+		t,
+		[]interface{}{
+			map[string]interface{}{
+				"matches":     []interface{}{map[string]interface{}{"path": map[string]interface{}{"type": "PathPrefix", "value": "/"}}},
+				"backendRefs": []interface{}{map[string]interface{}{"name": "gateway-linter", "port": float64(80)}},
+			},
+		},
+		spec["rules"],
+	)
+}
+
+// Test that the additional paths are rendered as rules around the application service rule, following the same
+// ordering as the Ingress paths, and that header matches, filters and weighted backends are rendered on the rules.
+func TestK8SServiceGatewayHTTPRouteRules(t *testing.T) {
+	t.Parallel()
+
+	routes := renderK8SServiceGatewayRoutesWithSetValues(
+		t,
+		map[string]string{
+			"gateway.enabled":                                       "true",
+			"gateway.parentRefs[0].name":                            "public",
+			"gateway.path":                                          "/app",
+			"gateway.headers[0].name":                               "x-env",
+			"gateway.headers[0].value":                              "prod",
+			"gateway.weight":                                        "90",
+			"gateway.backendRefs[0].name":                           "canary",
+			"gateway.backendRefs[0].port":                           "80",
+			"gateway.backendRefs[0].weight":                         "10",
+			"gateway.filters[0].type":                               "URLRewrite",
+			"gateway.filters[0].urlRewrite.path.type":               "ReplacePrefixMatch",
+			"gateway.filters[0].urlRewrite.path.replacePrefixMatch": "/",
+			"gateway.additionalPathsHigherPriority[0].path":         "/healthz",
+			"gateway.additionalPathsHigherPriority[0].pathType":     "Exact",
+			"gateway.additionalPathsHigherPriority[0].serviceName":  "health",
+			"gateway.additionalPathsHigherPriority[0].servicePort":  "8080",
+			"gateway.additionalPaths[0].path":                       "/static",
+			"gateway.additionalPaths[0].serviceName":                "cdn",
+			"gateway.additionalPaths[0].servicePort":                "80",
+		},
+	)
+	rules := routes["HTTPRoute"]["spec"].(map[string]interface{})["rules"].([]interface{})
+	require.Equal(t, 3, len(rules))
+
+	higherPriorityRule := rules[0].(map[string]interface{})
+	assert.Equal(
+		t,
+		[]interface{}{map[string]interface{}{"path": map[string]interface{}{"type": "Exact", "value": "/healthz"}}},
+		higherPriorityRule["matches"],
+	)
+	assert.Equal(t, []interface{}{map[string]interface{}{"name": "health", "port": float64(8080)}}, higherPriorityRule["backendRefs"])
+
+	appRule := rules[1].(map[string]interface{})
+	assert.Equal(
+		t,
+		[]interface{}{
+			map[string]interface{}{
+				"path":    map[string]interface{}{"type": "PathPrefix", "value": "/app"},
+				"headers": []interface{}{map[string]interface{}{"name": "x-env", "value": "prod"}},
+			},
+		},
+		appRule["matches"],
+	)
+	assert.Equal(
+		t,
+		[]interface{}{
+			map[string]interface{}{"name": "gateway-linter", "port": float64(80), "weight": float64(90)},
+			map[string]interface{}{"name": "canary", "port": float64(80), "weight": float64(10)},
+		},
+		appRule["backendRefs"],
+	)
+	filters := appRule["filters"].([]interface{})
+	require.Equal(t, 1, len(filters))
+	assert.Equal(t, "URLRewrite", filters[0].(map[string]interface{})["type"])
+
+	lowerPriorityRule := rules[2].(map[string]interface{})
+	assert.Equal(
+		t,
+		[]interface{}{map[string]interface{}{"path": map[string]interface{}{"type": "PathPrefix", "value": "/static"}}},
+		lowerPriorityRule["matches"],
+	)
+	assert.Equal(t, []interface{}{map[string]interface{}{"name": "cdn", "port": float64(80)}}, lowerPriorityRule["backendRefs"])
+}
+
+// Test that the GRPCRoute routes the method matches to the gRPC port of the service
+func TestK8SServiceGatewayGRPCRoute(t *testing.T) {
+	t.Parallel()
+
+	routes := renderK8SServiceGatewayRoutesWithSetValues(
+		t,
+		map[string]string{
+			"gateway.enabled":                        "true",
+			"gateway.parentRefs[0].name":             "public",
+			"gateway.hostnames[0]":                   "grpc.example.local",
+			"gateway.grpc.enabled":                   "true",
+			"gateway.grpc.servicePort":               "9090",
+			"gateway.grpc.matches[0].method.service": "helloworld.Greeter",
+		},
+	)
+	require.Contains(t, routes, "GRPCRoute")
+	spec := routes["GRPCRoute"]["spec"].(map[string]interface{})
+	assert.Equal(t, []interface{}{"grpc.example.local"}, spec["hostnames"])
+	assert.Equal(
+		t,
+		[]interface{}{
+			map[string]interface{}{
+				"matches":     []interface{}{map[string]interface{}{"method": map[string]interface{}{"service": "helloworld.Greeter"}}},
+				"backendRefs": []interface{}{map[string]interface{}{"name": "gateway-linter", "port": float64(9090)}},
+			},
+		},
+		spec["rules"],
+	)
+}
+
+// Test that named ports are resolved to the port number of the Service, as route backends can only refer to port numbers
+func TestK8SServiceGatewayResolvesNamedServicePort(t *testing.T) {
+	t.Parallel()
+
+	routes := renderK8SServiceGatewayRoutesWithSetValues(
+		t,
+		map[string]string{
+			"gateway.enabled":               "true",
+			"gateway.parentRefs[0].name":    "public",
+			"gateway.servicePort":           "app",
+			"service.ports.app.port":        "8080",
+			"service.ports.grpc.port":       "9090",
+			"service.ports.grpc.targetPort": "9090",
+			"gateway.grpc.enabled":          "true",
+			"gateway.grpc.servicePort":      "grpc",
+		},
+	)
+	httpRules := routes["HTTPRoute"]["spec"].(map[string]interface{})["rules"].([]interface{})
+	require.Equal(t, 1, len(httpRules))
+	assert.Equal(
+		t,
+		[]interface{}{map[string]interface{}{"name": "gateway-linter", "port": float64(8080)}},
+		httpRules[0].(map[string]interface{})["backendRefs"],
+	)
+
+	grpcRules := routes["GRPCRoute"]["spec"].(map[string]interface{})["rules"].([]interface{})
+	require.Equal(t, 1, len(grpcRules))
+	assert.Equal(
+		t,
+		[]interface{}{map[string]interface{}{"name": "gateway-linter", "port": float64(9090)}},
+		grpcRules[0].(map[string]interface{})["backendRefs"],
+	)
+}
+
+// Test that named ports that can not be resolved to a port number fail to render
+func TestK8SServiceGatewayRejectsUnresolvableNamedServicePort(t *testing.T) {
+	t.Parallel()
+
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name          string
+		setValues     map[string]string
+		expectedError string
+	}{
+		{
+			"unknown",
+			map[string]string{"gateway.servicePort": "metrics"},
+			"servicePort metrics of the gateway must be a port number or the name of a port in service.ports",
+		},
+		{
+			"otherService",
+			map[string]string{
+				"gateway.additionalPaths[0].path":        "/static",
+				"gateway.additionalPaths[0].serviceName": "cdn",
+				"gateway.additionalPaths[0].servicePort": "http",
+			},
+			"servicePort http must be a port number when routing the gateway to the Service cdn",
+		},
+	}
+
+	for _, testCase := range testCases {
+		// Capture range variable to force scope
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			setValues := map[string]string{
+				"gateway.enabled":            "true",
+				"gateway.parentRefs[0].name": "public",
+			}
+			for key, value := range testCase.setValues {
+				setValues[key] = value
+			}
+
+			// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values
+			// defined.
+			options := &helm.Options{
+				ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+				SetValues:   setValues,
+			}
+			_, err := helm.RenderTemplateE(t, options, helmChartPath, "gateway", []string{"templates/gateway.yaml"})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), testCase.expectedError)
+		})
+	}
+}
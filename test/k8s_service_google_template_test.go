@@ -0,0 +1,225 @@
+//go:build all || tpl
+// +build all tpl
+
+// NOTE: We use build flags to differentiate between template tests and integration tests so that you can conveniently
+// run just the template tests. See the test README for more information.
+
+package test
+
+import (
+	"path/filepath"
+	"testing"
+
+	certapi "github.com/GoogleCloudPlatform/gke-managed-certs/pkg/apis/networking.gke.io/v1beta1"
+	"github.com/ghodss/yaml"
+	"github.com/gruntwork-io/terratest/modules/helm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// renderK8SServiceGoogleResourceWithSetValues renders a single GKE resource (BackendConfig or FrontendConfig) as a map,
+// since there are no Go types available for these resources in this module.
+func renderK8SServiceGoogleResourceWithSetValues(t *testing.T, templateFile string, setValues map[string]string) map[string]interface{} {
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values defined.
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+		SetValues:   setValues,
+	}
+	out := helm.RenderTemplate(t, options, helmChartPath, "google", []string{templateFile})
+
+	rendered := map[string]interface{}{}
+	require.NoError(t, yaml.Unmarshal([]byte(out), &rendered))
+	return rendered
+}
+
+// renderK8SServiceManagedCertificatesWithSetValues renders all the ManagedCertificate resources, keyed by name.
+func renderK8SServiceManagedCertificatesWithSetValues(t *testing.T, setValues map[string]string) map[string]certapi.ManagedCertificate {
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values defined.
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+		SetValues:   setValues,
+	}
+	out := helm.RenderTemplate(t, options, helmChartPath, "gmc", []string{"templates/gmc.yaml"})
+
+	certs := map[string]certapi.ManagedCertificate{}
+	for _, document := range splitYamlDocuments(out) {
+		var cert certapi.ManagedCertificate
+		helm.UnmarshalK8SYaml(t, document, &cert)
+		certs[cert.Name] = cert
+	}
+	return certs
+}
+
+// Test that the GKE BackendConfig and FrontendConfig are not rendered by default, and that the Service and Ingress are
+// not annotated
+func TestK8SServiceGoogleConfigsNotRenderedByDefault(t *testing.T) {
+	t.Parallel()
+
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values defined.
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+	}
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "google", []string{"templates/backendconfig.yaml"})
+	require.Error(t, err)
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "google", []string{"templates/frontendconfig.yaml"})
+	require.Error(t, err)
+
+	service := renderK8SServiceWithSetValues(t, map[string]string{})
+	assert.NotContains(t, service.Annotations, "cloud.google.com/backend-config")
+	ingress := renderK8SServiceIngressWithSetValues(t, map[string]string{"ingress.enabled": "true"})
+	assert.NotContains(t, ingress.Annotations, "networking.gke.io/v1beta1.FrontendConfig")
+}
+
+// Test that the BackendConfig renders the configured features, and that the Service is annotated to use it
+func TestK8SServiceGoogleBackendConfig(t *testing.T) {
+	t.Parallel()
+
+	setValues := map[string]string{
+		"google.backendConfig.enabled":                 "true",
+		"google.backendConfig.healthCheck.type":        "HTTP",
+		"google.backendConfig.healthCheck.requestPath": "/healthz",
+		"google.backendConfig.healthCheck.port":        "8080",
+		"google.backendConfig.securityPolicy.name":     "acme-security-policy",
+		"google.backendConfig.cdn.enabled":             "true",
+		"google.backendConfig.timeoutSec":              "40",
+		"service.annotations.team":                     "web",
+	}
+	backendConfig := renderK8SServiceGoogleResourceWithSetValues(t, "templates/backendconfig.yaml", setValues)
+	assert.Equal(t, "cloud.google.com/v1", backendConfig["apiVersion"])
+	assert.Equal(t, "BackendConfig", backendConfig["kind"])
+	assert.Equal(t, "google-linter", backendConfig["metadata"].(map[string]interface{})["name"])
+	assert.Equal(
+		t,
+		map[string]interface{}{
+			"healthCheck":    map[string]interface{}{"type": "HTTP", "requestPath": "/healthz", "port": float64(8080)},
+			"securityPolicy": map[string]interface{}{"name": "acme-security-policy"},
+			"cdn":            map[string]interface{}{"enabled": true},
+			"timeoutSec":     float64(40),
+		},
+		backendConfig["spec"],
+	)
+
+	// The Service is rendered with a different release name by its render helper
+	service := renderK8SServiceWithSetValues(t, setValues)
+	assert.Equal(t, `{"default":"service-linter"}`, service.Annotations["cloud.google.com/backend-config"])
+	assert.Equal(t, "web", service.Annotations["team"])
+}
+
+// Test that the BackendConfig name can be overridden, and that the Service annotation follows it
+func TestK8SServiceGoogleBackendConfigName(t *testing.T) {
+	t.Parallel()
+
+	setValues := map[string]string{
+		"google.backendConfig.enabled":    "true",
+		"google.backendConfig.name":       "acme-backend",
+		"google.backendConfig.timeoutSec": "40",
+	}
+	backendConfig := renderK8SServiceGoogleResourceWithSetValues(t, "templates/backendconfig.yaml", setValues)
+	assert.Equal(t, "acme-backend", backendConfig["metadata"].(map[string]interface{})["name"])
+	assert.Equal(t, map[string]interface{}{"timeoutSec": float64(40)}, backendConfig["spec"])
+
+	service := renderK8SServiceWithSetValues(t, setValues)
+	assert.Equal(t, `{"default":"acme-backend"}`, service.Annotations["cloud.google.com/backend-config"])
+}
+
+// Test that the FrontendConfig renders the HTTPS redirect and SSL policy, and that the main Ingress is annotated to use
+// it
+func TestK8SServiceGoogleFrontendConfig(t *testing.T) {
+	t.Parallel()
+
+	setValues := map[string]string{
+		"google.frontendConfig.enabled":                          "true",
+		"google.frontendConfig.redirectToHttps.enabled":          "true",
+		"google.frontendConfig.redirectToHttps.responseCodeName": "MOVED_PERMANENTLY_DEFAULT",
+		"google.frontendConfig.sslPolicy":                        "acme-ssl-policy",
+		"ingress.enabled":                                        "true",
+		"ingress.path":                                           "/",
+		"ingress.pathType":                                       "Prefix",
+		"ingress.servicePort":                                    "app",
+		"ingress.annotations.kubernetes\\.io/ingress\\.class":    "gce",
+	}
+	frontendConfig := renderK8SServiceGoogleResourceWithSetValues(t, "templates/frontendconfig.yaml", setValues)
+	assert.Equal(t, "networking.gke.io/v1beta1", frontendConfig["apiVersion"])
+	assert.Equal(t, "FrontendConfig", frontendConfig["kind"])
+	assert.Equal(t, "google-linter", frontendConfig["metadata"].(map[string]interface{})["name"])
+	assert.Equal(
+		t,
+		map[string]interface{}{
+			"redirectToHttps": map[string]interface{}{"enabled": true, "responseCodeName": "MOVED_PERMANENTLY_DEFAULT"},
+			"sslPolicy":       "acme-ssl-policy",
+		},
+		frontendConfig["spec"],
+	)
+
+	// The Ingress is rendered with a different release name by its render helper
+	ingress := renderK8SServiceIngressWithSetValues(t, setValues)
+	assert.Equal(t, "ingress-linter", ingress.Annotations["networking.gke.io/v1beta1.FrontendConfig"])
+	assert.Equal(t, "gce", ingress.Annotations["kubernetes.io/ingress.class"])
+}
+
+// Test that the additional Ingresses configured with ingresses are also annotated to use the FrontendConfig
+func TestK8SServiceGoogleFrontendConfigAnnotatesAdditionalIngresses(t *testing.T) {
+	t.Parallel()
+
+	ingresses := renderK8SServiceIngressesWithSetValues(
+		t,
+		map[string]string{
+			"google.frontendConfig.enabled":                                  "true",
+			"ingresses.internal.path":                                        "/",
+			"ingresses.internal.pathType":                                    "Prefix",
+			"ingresses.internal.servicePort":                                 "app",
+			"ingresses.internal.annotations.kubernetes\\.io/ingress\\.class": "gce-internal",
+			"ingresses.public.path":                                          "/",
+			"ingresses.public.pathType":                                      "Prefix",
+			"ingresses.public.servicePort":                                   "app",
+		},
+	)
+
+	require.Equal(t, len(ingresses), 2)
+	for _, ingress := range ingresses {
+		assert.Equal(t, "ingress-linter", ingress.Annotations["networking.gke.io/v1beta1.FrontendConfig"])
+	}
+	assert.Equal(t, "gce-internal", ingresses[0].Annotations["kubernetes.io/ingress.class"])
+}
+
+// Test that the ManagedCertificate covers all the configured domains, and that additional certificates are rendered for
+// each entry of google.managedCertificate.certificates
+func TestK8SServiceManagedCertificateMultipleDomainsAndCertificates(t *testing.T) {
+	t.Parallel()
+
+	certs := renderK8SServiceManagedCertificatesWithSetValues(
+		t,
+		map[string]string{
+			"google.managedCertificate.enabled":                                    "true",
+			"google.managedCertificate.name":                                       "acme-cert",
+			"google.managedCertificate.domainName":                                 "api.acme.io",
+			"google.managedCertificate.domainNames[0]":                             "admin.acme.io",
+			"google.managedCertificate.certificates.acme-www-cert.domainNames[0]":  "acme.io",
+			"google.managedCertificate.certificates.acme-www-cert.domainNames[1]":  "www.acme.io",
+			"google.managedCertificate.certificates.acme-blog-cert.domainNames[0]": "blog.acme.io",
+		},
+	)
+	require.Equal(t, 3, len(certs))
+
+	require.Contains(t, certs, "acme-cert")
+	assert.Equal(t, "networking.gke.io/v1", certs["acme-cert"].APIVersion)
+	assert.Equal(t, []string{"api.acme.io", "admin.acme.io"}, certs["acme-cert"].Spec.Domains)
+
+	require.Contains(t, certs, "acme-www-cert")
+	assert.Equal(t, "networking.gke.io/v1", certs["acme-www-cert"].APIVersion)
+	assert.Equal(t, []string{"acme.io", "www.acme.io"}, certs["acme-www-cert"].Spec.Domains)
+
+	// Certificates with a single domain keep using the v1beta1 API when the cluster capabilities are not known
+	require.Contains(t, certs, "acme-blog-cert")
+	assert.Equal(t, "networking.gke.io/v1beta1", certs["acme-blog-cert"].APIVersion)
+	assert.Equal(t, []string{"blog.acme.io"}, certs["acme-blog-cert"].Spec.Domains)
+}
@@ -0,0 +1,166 @@
+//go:build all || tpl
+// +build all tpl
+
+// NOTE: We use build flags to differentiate between template tests and integration tests so that you can conveniently
+// run just the template tests. See the test README for more information.
+
+package test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/helm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Test that no jobs are rendered by default
+func TestK8SServiceJobsNotRenderedByDefault(t *testing.T) {
+	t.Parallel()
+
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values defined.
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+	}
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "jobs", []string{"templates/jobs.yaml"})
+	require.Error(t, err)
+}
+
+// Test that a job without a schedule renders a one-off Job that inherits the config of the main container
+func TestK8SServiceJobInheritsMainContainerConfig(t *testing.T) {
+	t.Parallel()
+
+	job := renderK8SServiceJobWithSetValues(
+		t,
+		map[string]string{
+			"envVars.DB_HOST":                      "mysql.default.svc.cluster.local",
+			"configMaps.dbsettings.as":             "envFrom",
+			"secrets.dbpassword.as":                "volume",
+			"secrets.dbpassword.mountPath":         "/etc/db",
+			"serviceAccount.name":                  "migrations",
+			"sideCarContainers.datadog.image":      "datadog/agent:latest",
+			"livenessProbe.httpGet.port":           "http",
+			"jobs.migrate.containerArgs[0]":        "migrate",
+			"jobs.migrate.backoffLimit":            "0",
+			"jobs.migrate.ttlSecondsAfterFinished": "600",
+		},
+	)
+
+	assert.Equal(t, "jobs-linter-migrate", job.Name)
+	assert.Equal(t, int32(0), *job.Spec.BackoffLimit)
+	assert.Equal(t, int32(600), *job.Spec.TTLSecondsAfterFinished)
+
+	// Verify that the Pods are not selected by the Service of the application
+	podLabels := job.Spec.Template.Labels
+	assert.Equal(t, "jobs-linter-migrate", podLabels["app.kubernetes.io/name"])
+	assert.Equal(t, "migrate", podLabels["gruntwork.io/job-name"])
+
+	podSpec := job.Spec.Template.Spec
+	assert.Equal(t, corev1.RestartPolicyNever, podSpec.RestartPolicy)
+	assert.Equal(t, "migrations", podSpec.ServiceAccountName)
+
+	// Verify that only the job container is rendered, without the side cars, ports and probes
+	require.Equal(t, len(podSpec.Containers), 1)
+	jobContainer := podSpec.Containers[0]
+	assert.Equal(t, "migrate", jobContainer.Name)
+	assert.Equal(t, "nginx:stable", jobContainer.Image)
+	assert.Equal(t, []string{"migrate"}, jobContainer.Args)
+	assert.Equal(t, len(jobContainer.Ports), 0)
+	assert.Nil(t, jobContainer.LivenessProbe)
+	assert.Nil(t, jobContainer.Lifecycle)
+
+	// Verify that the env vars, ConfigMaps and Secrets are injected the same way as the main container
+	require.Equal(t, len(jobContainer.Env), 1)
+	assert.Equal(t, "DB_HOST", jobContainer.Env[0].Name)
+	require.Equal(t, len(jobContainer.EnvFrom), 1)
+	assert.Equal(t, "dbsettings", jobContainer.EnvFrom[0].ConfigMapRef.Name)
+	require.Equal(t, len(jobContainer.VolumeMounts), 1)
+	assert.Equal(t, "/etc/db", jobContainer.VolumeMounts[0].MountPath)
+	require.Equal(t, len(podSpec.Volumes), 1)
+	assert.Equal(t, "dbpassword", podSpec.Volumes[0].Secret.SecretName)
+}
+
+// Test that the container settings of a job can be overridden
+func TestK8SServiceJobOverridesContainerConfig(t *testing.T) {
+	t.Parallel()
+
+	job := renderK8SServiceJobWithSetValues(
+		t,
+		map[string]string{
+			"containerCommand[0]":                          "/bin/server",
+			"containerArgs[0]":                             "--port=80",
+			"jobs.report.containerImage.tag":               "v2",
+			"jobs.report.containerCommand[0]":              "/bin/report",
+			"jobs.report.containerArgs":                    "null",
+			"jobs.report.containerResources.limits.memory": "1Gi",
+			"jobs.report.restartPolicy":                    "OnFailure",
+		},
+	)
+
+	podSpec := job.Spec.Template.Spec
+	assert.Equal(t, corev1.RestartPolicyOnFailure, podSpec.RestartPolicy)
+	require.Equal(t, len(podSpec.Containers), 1)
+	jobContainer := podSpec.Containers[0]
+	assert.Equal(t, "nginx:v2", jobContainer.Image)
+	assert.Equal(t, []string{"/bin/report"}, jobContainer.Command)
+	assert.Equal(t, len(jobContainer.Args), 0)
+	memoryLimit := jobContainer.Resources.Limits[corev1.ResourceMemory]
+	assert.Equal(t, "1Gi", memoryLimit.String())
+}
+
+// Test that a job with a schedule renders a CronJob
+func TestK8SServiceJobWithScheduleRendersCronJob(t *testing.T) {
+	t.Parallel()
+
+	cronjob := renderK8SServiceCronJobWithSetValues(
+		t,
+		map[string]string{
+			"kubeVersionOverride":                "1.25.0",
+			"envVars.DB_HOST":                    "mysql.default.svc.cluster.local",
+			"jobs.report.schedule":               "*/5 * * * *",
+			"jobs.report.concurrencyPolicy":      "Forbid",
+			"jobs.report.containerCommand[0]":    "/bin/report",
+			"jobs.report.backoffLimit":           "3",
+			"jobs.report.failedJobsHistoryLimit": "1",
+		},
+	)
+
+	assert.Equal(t, "batch/v1", cronjob.APIVersion)
+	assert.Equal(t, "CronJob", cronjob.Kind)
+	assert.Equal(t, "*/5 * * * *", cronjob.Spec.Schedule)
+	assert.Equal(t, batchv1.ForbidConcurrent, cronjob.Spec.ConcurrencyPolicy)
+	assert.Equal(t, int32(1), *cronjob.Spec.FailedJobsHistoryLimit)
+
+	jobSpec := cronjob.Spec.JobTemplate.Spec
+	assert.Equal(t, int32(3), *jobSpec.BackoffLimit)
+	require.Equal(t, len(jobSpec.Template.Spec.Containers), 1)
+	jobContainer := jobSpec.Template.Spec.Containers[0]
+	assert.Equal(t, []string{"/bin/report"}, jobContainer.Command)
+	require.Equal(t, len(jobContainer.Env), 1)
+	assert.Equal(t, "DB_HOST", jobContainer.Env[0].Name)
+}
+
+// Test that the Helm hook annotations are rendered on the Job
+func TestK8SServiceJobHelmHookAnnotations(t *testing.T) {
+	t.Parallel()
+
+	job := renderK8SServiceJobWithSetValues(
+		t,
+		map[string]string{
+			"jobs.migrate.helmHook.events[0]":       "pre-install",
+			"jobs.migrate.helmHook.events[1]":       "pre-upgrade",
+			"jobs.migrate.helmHook.weight":          "-5",
+			"jobs.migrate.helmHook.deletePolicy[0]": "hook-succeeded",
+		},
+	)
+
+	assert.Equal(t, "pre-install,pre-upgrade", job.Annotations["helm.sh/hook"])
+	assert.Equal(t, "-5", job.Annotations["helm.sh/hook-weight"])
+	assert.Equal(t, "hook-succeeded", job.Annotations["helm.sh/hook-delete-policy"])
+}
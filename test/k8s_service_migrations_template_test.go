@@ -0,0 +1,149 @@
+//go:build all || tpl
+// +build all tpl
+
+// NOTE: We use build flags to differentiate between template tests and integration tests so that you can conveniently
+// run just the template tests. See the test README for more information.
+
+package test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/helm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Test that the migrations Job is not rendered by default
+func TestK8SServiceMigrationsNotRenderedByDefault(t *testing.T) {
+	t.Parallel()
+
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values defined.
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+	}
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "migrations", []string{"templates/migrations.yaml"})
+	require.Error(t, err)
+}
+
+// Test that the migrations Job runs as a pre-install and pre-upgrade hook
+func TestK8SServiceMigrationsHelmHookAnnotations(t *testing.T) {
+	t.Parallel()
+
+	job := renderK8SServiceMigrationsJobWithSetValues(
+		t,
+		map[string]string{
+			"migrations.enabled":         "true",
+			"migrations.hookWeight":      "-5",
+			"migrations.deletePolicy[0]": "before-hook-creation",
+			"migrations.deletePolicy[1]": "hook-succeeded",
+		},
+	)
+
+	assert.Equal(t, "migrations-linter-migrations", job.Name)
+	assert.Equal(t, "pre-install,pre-upgrade", job.Annotations["helm.sh/hook"])
+	assert.Equal(t, "-5", job.Annotations["helm.sh/hook-weight"])
+	assert.Equal(t, "before-hook-creation,hook-succeeded", job.Annotations["helm.sh/hook-delete-policy"])
+	assert.Equal(t, int32(0), *job.Spec.BackoffLimit)
+}
+
+// Test that the migrations Job inherits the image and config of the main container
+func TestK8SServiceMigrationsInheritsMainContainerConfig(t *testing.T) {
+	t.Parallel()
+
+	job := renderK8SServiceMigrationsJobWithSetValues(
+		t,
+		map[string]string{
+			"migrations.enabled":           "true",
+			"migrations.containerArgs[0]":  "migrate",
+			"containerImage.tag":           "v1.2.3",
+			"containerArgs[0]":             "serve",
+			"envVars.DB_HOST":              "mysql.default.svc.cluster.local",
+			"configMaps.dbsettings.as":     "envFrom",
+			"secrets.dbpassword.as":        "volume",
+			"secrets.dbpassword.mountPath": "/etc/db",
+			"serviceAccount.name":          "migrations",
+		},
+	)
+
+	podSpec := job.Spec.Template.Spec
+	assert.Equal(t, "migrations", podSpec.ServiceAccountName)
+	require.Equal(t, len(podSpec.Containers), 1)
+	migrationsContainer := podSpec.Containers[0]
+	assert.Equal(t, "migrations", migrationsContainer.Name)
+	assert.Equal(t, "nginx:v1.2.3", migrationsContainer.Image)
+	assert.Equal(t, []string{"migrate"}, migrationsContainer.Args)
+
+	require.Equal(t, len(migrationsContainer.Env), 1)
+	assert.Equal(t, "DB_HOST", migrationsContainer.Env[0].Name)
+	require.Equal(t, len(migrationsContainer.EnvFrom), 1)
+	assert.Equal(t, "dbsettings", migrationsContainer.EnvFrom[0].ConfigMapRef.Name)
+	require.Equal(t, len(migrationsContainer.VolumeMounts), 1)
+	assert.Equal(t, "/etc/db", migrationsContainer.VolumeMounts[0].MountPath)
+	require.Equal(t, len(podSpec.Volumes), 1)
+	assert.Equal(t, "dbpassword", podSpec.Volumes[0].Secret.SecretName)
+}
+
+// Test that the migrations Job runs with the ServiceAccount and ConfigMap created by the chart, which are rendered as
+// hooks that run before the migrations on the first install, and as regular resources on upgrades.
+func TestK8SServiceMigrationsRunWithChartManagedDependencies(t *testing.T) {
+	t.Parallel()
+
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values defined.
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+		SetValues: map[string]string{
+			"migrations.enabled":                 "true",
+			"migrations.hookWeight":              "-5",
+			"serviceAccount.create":              "true",
+			"serviceAccount.name":                "migrations",
+			"configMaps.dbsettings.as":           "envFrom",
+			"configMaps.dbsettings.data.DB_HOST": "mysql",
+		},
+	}
+	templates := []string{"templates/migrations.yaml", "templates/serviceaccount.yaml", "templates/configmaps.yaml"}
+	out := helm.RenderTemplate(t, options, helmChartPath, "migrations", templates)
+	documents := splitYamlDocuments(out)
+	require.Equal(t, 3, len(documents))
+
+	var job batchv1.Job
+	helm.UnmarshalK8SYaml(t, documents[0], &job)
+	podSpec := job.Spec.Template.Spec
+	assert.Equal(t, "migrations", podSpec.ServiceAccountName)
+	require.Equal(t, len(podSpec.Containers[0].EnvFrom), 1)
+	assert.Equal(t, "dbsettings", podSpec.Containers[0].EnvFrom[0].ConfigMapRef.Name)
+
+	var serviceAccount corev1.ServiceAccount
+	helm.UnmarshalK8SYaml(t, documents[1], &serviceAccount)
+	var configMap corev1.ConfigMap
+	helm.UnmarshalK8SYaml(t, documents[2], &configMap)
+	assert.Equal(t, "migrations", serviceAccount.Name)
+	assert.Equal(t, "dbsettings", configMap.Name)
+	for _, objectMeta := range []metav1.ObjectMeta{serviceAccount.ObjectMeta, configMap.ObjectMeta} {
+		assert.Equal(t, "pre-install", objectMeta.Annotations["helm.sh/hook"])
+		assert.Equal(t, "-6", objectMeta.Annotations["helm.sh/hook-weight"])
+		assert.Equal(t, "migrations", objectMeta.Annotations["meta.helm.sh/release-name"])
+		assert.Equal(t, "Helm", objectMeta.Labels["app.kubernetes.io/managed-by"])
+	}
+
+	// On upgrades, the dependencies already exist, so they are rendered as regular resources
+	out = helm.RenderTemplate(t, options, helmChartPath, "migrations", templates, "--is-upgrade")
+	documents = splitYamlDocuments(out)
+	require.Equal(t, 3, len(documents))
+	var upgradeServiceAccount corev1.ServiceAccount
+	helm.UnmarshalK8SYaml(t, documents[1], &upgradeServiceAccount)
+	var upgradeConfigMap corev1.ConfigMap
+	helm.UnmarshalK8SYaml(t, documents[2], &upgradeConfigMap)
+	assert.NotContains(t, upgradeServiceAccount.Annotations, "helm.sh/hook")
+	assert.NotContains(t, upgradeConfigMap.Annotations, "helm.sh/hook")
+}
@@ -0,0 +1,168 @@
+//go:build all || tpl
+// +build all tpl
+
+// NOTE: We use build flags to differentiate between template tests and integration tests so that you can conveniently
+// run just the template tests. See the test README for more information.
+
+package test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/helm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+)
+
+// Test that the NetworkPolicy is not rendered by default
+func TestK8SServiceNetworkPolicyNotRenderedByDefault(t *testing.T) {
+	t.Parallel()
+
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values defined.
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+	}
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "networkpolicy", []string{"templates/networkpolicy.yaml"})
+	require.Error(t, err)
+}
+
+// Test that the NetworkPolicy selects the Pods of the release and only allows traffic to the enabled containerPorts from
+// any source when no allow-lists are set.
+func TestK8SServiceNetworkPolicyAllowsEnabledContainerPorts(t *testing.T) {
+	t.Parallel()
+
+	networkPolicy := renderK8SServiceNetworkPolicyWithSetValues(
+		t,
+		map[string]string{
+			"networkPolicy.enabled":           "true",
+			"containerPorts.metrics.port":     "9090",
+			"containerPorts.metrics.protocol": "TCP",
+			"containerPorts.debug.port":       "5000",
+			"containerPorts.debug.protocol":   "TCP",
+			"containerPorts.debug.disabled":   "true",
+		},
+	)
+
+	assert.Equal(t, "networkpolicy-linter", networkPolicy.Name)
+	assert.Equal(t, "linter", networkPolicy.Spec.PodSelector.MatchLabels["app.kubernetes.io/name"])
+	assert.Equal(t, "networkpolicy", networkPolicy.Spec.PodSelector.MatchLabels["app.kubernetes.io/instance"])
+	assert.Equal(t, []networkingv1.PolicyType{networkingv1.PolicyTypeIngress}, networkPolicy.Spec.PolicyTypes)
+	assert.Equal(t, len(networkPolicy.Spec.Egress), 0)
+
+	require.Equal(t, len(networkPolicy.Spec.Ingress), 1)
+	rule := networkPolicy.Spec.Ingress[0]
+	assert.Equal(t, len(rule.From), 0)
+	require.Equal(t, len(rule.Ports), 2)
+	ports := []int{rule.Ports[0].Port.IntValue(), rule.Ports[1].Port.IntValue()}
+	assert.ElementsMatch(t, []int{80, 9090}, ports)
+	assert.Equal(t, corev1.ProtocolTCP, *rule.Ports[0].Protocol)
+}
+
+// Test that the NetworkPolicy denies all inbound traffic when all the containerPorts are disabled
+func TestK8SServiceNetworkPolicyDeniesIngressWithoutContainerPorts(t *testing.T) {
+	t.Parallel()
+
+	networkPolicy := renderK8SServiceNetworkPolicyWithSetValues(
+		t,
+		map[string]string{
+			"networkPolicy.enabled":        "true",
+			"containerPorts.http.disabled": "true",
+		},
+	)
+
+	assert.Equal(t, []networkingv1.PolicyType{networkingv1.PolicyTypeIngress}, networkPolicy.Spec.PolicyTypes)
+	assert.Equal(t, len(networkPolicy.Spec.Ingress), 0)
+}
+
+// Test that the ingress allow-lists render the namespace, pod and CIDR peers
+func TestK8SServiceNetworkPolicyIngressAllowLists(t *testing.T) {
+	t.Parallel()
+
+	networkPolicy := renderK8SServiceNetworkPolicyWithSetValues(
+		t,
+		map[string]string{
+			"networkPolicy.enabled": "true",
+			"networkPolicy.ingress.namespaceLabels[0].kubernetes\\.io/metadata\\.name": "ingress-nginx",
+			"networkPolicy.ingress.podLabels[0].app\\.kubernetes\\.io/name":            "frontend",
+			"networkPolicy.ingress.cidrs[0]":                                           "10.0.0.0/8",
+		},
+	)
+
+	require.Equal(t, len(networkPolicy.Spec.Ingress), 1)
+	rule := networkPolicy.Spec.Ingress[0]
+	require.Equal(t, len(rule.Ports), 1)
+	assert.Equal(t, 80, rule.Ports[0].Port.IntValue())
+
+	require.Equal(t, len(rule.From), 3)
+	assert.Equal(t, "ingress-nginx", rule.From[0].NamespaceSelector.MatchLabels["kubernetes.io/metadata.name"])
+	assert.Nil(t, rule.From[0].PodSelector)
+	assert.Equal(t, "frontend", rule.From[1].PodSelector.MatchLabels["app.kubernetes.io/name"])
+	assert.Nil(t, rule.From[1].NamespaceSelector)
+	assert.Equal(t, "10.0.0.0/8", rule.From[2].IPBlock.CIDR)
+}
+
+// Test that enabling the egress rules restricts outbound traffic, allowing DNS and the configured allow-lists
+func TestK8SServiceNetworkPolicyEgressAllowsDNSAndAllowLists(t *testing.T) {
+	t.Parallel()
+
+	networkPolicy := renderK8SServiceNetworkPolicyWithSetValues(
+		t,
+		map[string]string{
+			"networkPolicy.enabled":                                        "true",
+			"networkPolicy.egress.enabled":                                 "true",
+			"networkPolicy.egress.podLabels[0].app\\.kubernetes\\.io/name": "postgresql",
+			"networkPolicy.egress.cidrs[0]":                                "192.168.0.0/16",
+			"networkPolicy.egress.ports[0].port":                           "5432",
+			"networkPolicy.egress.ports[0].protocol":                       "TCP",
+		},
+	)
+
+	assert.Equal(
+		t,
+		[]networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress},
+		networkPolicy.Spec.PolicyTypes,
+	)
+	require.Equal(t, len(networkPolicy.Spec.Egress), 2)
+
+	dnsRule := networkPolicy.Spec.Egress[0]
+	assert.Equal(t, len(dnsRule.To), 0)
+	require.Equal(t, len(dnsRule.Ports), 2)
+	assert.Equal(t, 53, dnsRule.Ports[0].Port.IntValue())
+	assert.Equal(t, corev1.ProtocolUDP, *dnsRule.Ports[0].Protocol)
+	assert.Equal(t, 53, dnsRule.Ports[1].Port.IntValue())
+	assert.Equal(t, corev1.ProtocolTCP, *dnsRule.Ports[1].Protocol)
+
+	allowRule := networkPolicy.Spec.Egress[1]
+	require.Equal(t, len(allowRule.To), 2)
+	assert.Equal(t, "postgresql", allowRule.To[0].PodSelector.MatchLabels["app.kubernetes.io/name"])
+	assert.Equal(t, "192.168.0.0/16", allowRule.To[1].IPBlock.CIDR)
+	require.Equal(t, len(allowRule.Ports), 1)
+	assert.Equal(t, 5432, allowRule.Ports[0].Port.IntValue())
+}
+
+// Test that DNS can be disallowed when the egress rules are enabled
+func TestK8SServiceNetworkPolicyEgressWithoutDNS(t *testing.T) {
+	t.Parallel()
+
+	networkPolicy := renderK8SServiceNetworkPolicyWithSetValues(
+		t,
+		map[string]string{
+			"networkPolicy.enabled":         "true",
+			"networkPolicy.egress.enabled":  "true",
+			"networkPolicy.egress.allowDNS": "false",
+		},
+	)
+
+	assert.Equal(
+		t,
+		[]networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress},
+		networkPolicy.Spec.PolicyTypes,
+	)
+	assert.Equal(t, len(networkPolicy.Spec.Egress), 0)
+}
@@ -0,0 +1,147 @@
+//go:build all || tpl
+// +build all tpl
+
+// NOTE: We use build flags to differentiate between template tests and integration tests so that you can conveniently
+// run just the template tests. See the test README for more information.
+
+package test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/helm"
+	"github.com/gruntwork-io/terratest/modules/k8s"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	rbacv1 "k8s.io/api/rbac/v1"
+)
+
+// renderK8SServiceRBACWithSetValues renders the RBAC resources into the given namespace, and returns the documents keyed
+// by the kind of the resource.
+func renderK8SServiceRBACWithSetValues(t *testing.T, namespace string, setValues map[string]string) map[string]string {
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values defined.
+	options := &helm.Options{
+		ValuesFiles:    []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+		SetValues:      setValues,
+		KubectlOptions: k8s.NewKubectlOptions("", "", namespace),
+	}
+	out := helm.RenderTemplate(t, options, helmChartPath, "rbac", []string{"templates/rbac.yaml"})
+
+	documentsByKind := map[string]string{}
+	for _, document := range splitYamlDocuments(out) {
+		for _, kind := range []string{"Role", "RoleBinding", "ClusterRole", "ClusterRoleBinding"} {
+			if strings.Contains(document, "\nkind: "+kind+"\n") {
+				documentsByKind[kind] = document
+			}
+		}
+	}
+	return documentsByKind
+}
+
+// Test that no RBAC resources are rendered by default
+func TestK8SServiceRBACNotRenderedByDefault(t *testing.T) {
+	t.Parallel()
+
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values defined.
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+		SetValues:   map[string]string{"serviceAccount.name": "my-app"},
+	}
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "rbac", []string{"templates/rbac.yaml"})
+	require.Error(t, err)
+}
+
+// Test that setting rbac.rules renders a Role bound to the service account in the release namespace
+func TestK8SServiceRBACRulesRenderRoleAndRoleBinding(t *testing.T) {
+	t.Parallel()
+
+	documents := renderK8SServiceRBACWithSetValues(
+		t,
+		"my-namespace",
+		map[string]string{
+			"serviceAccount.name":                       "my-app",
+			"serviceAccount.create":                     "true",
+			"serviceAccount.rbac.rules[0].apiGroups[0]": "coordination.k8s.io",
+			"serviceAccount.rbac.rules[0].resources[0]": "leases",
+			"serviceAccount.rbac.rules[0].verbs[0]":     "get",
+			"serviceAccount.rbac.rules[0].verbs[1]":     "update",
+		},
+	)
+	require.Equal(t, len(documents), 2)
+
+	var role rbacv1.Role
+	helm.UnmarshalK8SYaml(t, documents["Role"], &role)
+	assert.Equal(t, "rbac-linter", role.Name)
+	assert.Equal(t, "my-namespace", role.Namespace)
+	require.Equal(t, len(role.Rules), 1)
+	assert.Equal(t, []string{"coordination.k8s.io"}, role.Rules[0].APIGroups)
+	assert.Equal(t, []string{"leases"}, role.Rules[0].Resources)
+	assert.Equal(t, []string{"get", "update"}, role.Rules[0].Verbs)
+
+	var roleBinding rbacv1.RoleBinding
+	helm.UnmarshalK8SYaml(t, documents["RoleBinding"], &roleBinding)
+	assert.Equal(t, "my-namespace", roleBinding.Namespace)
+	assert.Equal(t, "Role", roleBinding.RoleRef.Kind)
+	assert.Equal(t, role.Name, roleBinding.RoleRef.Name)
+	require.Equal(t, len(roleBinding.Subjects), 1)
+	assert.Equal(t, "ServiceAccount", roleBinding.Subjects[0].Kind)
+	assert.Equal(t, "my-app", roleBinding.Subjects[0].Name)
+	assert.Equal(t, "my-namespace", roleBinding.Subjects[0].Namespace)
+}
+
+// Test that setting rbac.clusterRules renders a ClusterRole bound to the service account in the release namespace
+func TestK8SServiceRBACClusterRulesRenderClusterRoleAndClusterRoleBinding(t *testing.T) {
+	t.Parallel()
+
+	documents := renderK8SServiceRBACWithSetValues(
+		t,
+		"my-namespace",
+		map[string]string{
+			"serviceAccount.name":                              "my-app",
+			"serviceAccount.rbac.clusterRules[0].apiGroups[0]": "",
+			"serviceAccount.rbac.clusterRules[0].resources[0]": "nodes",
+			"serviceAccount.rbac.clusterRules[0].verbs[0]":     "list",
+		},
+	)
+	require.Equal(t, len(documents), 2)
+
+	var clusterRole rbacv1.ClusterRole
+	helm.UnmarshalK8SYaml(t, documents["ClusterRole"], &clusterRole)
+	assert.Equal(t, "my-namespace-rbac-linter", clusterRole.Name)
+	require.Equal(t, len(clusterRole.Rules), 1)
+	assert.Equal(t, []string{"nodes"}, clusterRole.Rules[0].Resources)
+
+	var clusterRoleBinding rbacv1.ClusterRoleBinding
+	helm.UnmarshalK8SYaml(t, documents["ClusterRoleBinding"], &clusterRoleBinding)
+	assert.Equal(t, "ClusterRole", clusterRoleBinding.RoleRef.Kind)
+	assert.Equal(t, clusterRole.Name, clusterRoleBinding.RoleRef.Name)
+	require.Equal(t, len(clusterRoleBinding.Subjects), 1)
+	assert.Equal(t, "ServiceAccount", clusterRoleBinding.Subjects[0].Kind)
+	assert.Equal(t, "my-app", clusterRoleBinding.Subjects[0].Name)
+	assert.Equal(t, "my-namespace", clusterRoleBinding.Subjects[0].Namespace)
+}
+
+// Test that RBAC rules require the name of the service account
+func TestK8SServiceRBACRequiresServiceAccountName(t *testing.T) {
+	t.Parallel()
+
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values defined.
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+		SetValues:   map[string]string{"serviceAccount.rbac.rules[0].verbs[0]": "get"},
+	}
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "rbac", []string{"templates/rbac.yaml"})
+	require.Error(t, err)
+	assert.True(t, strings.Contains(err.Error(), "serviceAccount.name is required when serviceAccount.rbac rules are set"))
+}
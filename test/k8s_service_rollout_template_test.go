@@ -0,0 +1,233 @@
+//go:build all || tpl
+// +build all tpl
+
+// NOTE: We use build flags to differentiate between template tests and integration tests so that you can conveniently
+// run just the template tests. See the test README for more information.
+
+package test
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"github.com/gruntwork-io/terratest/modules/helm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+)
+
+// Test that the Rollout is not rendered by default
+func TestK8SServiceRolloutNotRenderedByDefault(t *testing.T) {
+	t.Parallel()
+
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values defined.
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+	}
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "rollout", []string{"templates/rollout.yaml"})
+	require.Error(t, err)
+}
+
+// Test that enabling the rollout renders a Rollout with the canary strategy in place of the Deployment, with the stable
+// and canary Services set automatically.
+func TestK8SServiceRolloutCanaryStrategy(t *testing.T) {
+	t.Parallel()
+
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values defined.
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+		SetValues: map[string]string{
+			"rollout.enabled": "true",
+			"rollout.strategy.canary.steps[0].setWeight":      "20",
+			"rollout.strategy.canary.steps[1].pause.duration": "10m",
+			"envVars.DB_HOST": "mysql.default.svc.cluster.local",
+		},
+	}
+
+	// The Deployment is replaced by the Rollout
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "rollout", []string{"templates/deployment.yaml"})
+	require.Error(t, err)
+
+	out := helm.RenderTemplate(t, options, helmChartPath, "rollout", []string{"templates/rollout.yaml"})
+
+	// The Pod template of the Rollout is the same as the Deployment, so we can parse it with the Deployment type
+	var rollout appsv1.Deployment
+	helm.UnmarshalK8SYaml(t, out, &rollout)
+	assert.Equal(t, "argoproj.io/v1alpha1", rollout.APIVersion)
+	assert.Equal(t, "Rollout", rollout.Kind)
+	assert.Equal(t, "rollout-linter", rollout.Name)
+	require.Equal(t, len(rollout.Spec.Template.Spec.Containers), 1)
+	appContainer := rollout.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, "nginx:stable", appContainer.Image)
+	require.Equal(t, len(appContainer.Env), 1)
+	assert.Equal(t, "DB_HOST", appContainer.Env[0].Name)
+
+	// We take the output and render it to a map to validate the strategy, which is specific to the Rollout
+	rendered := map[string]interface{}{}
+	err = yaml.Unmarshal([]byte(out), &rendered)
+	require.NoError(t, err)
+	strategy := rendered["spec"].(map[string]interface{})["strategy"].(map[string]interface{})
+	canary := strategy["canary"].(map[string]interface{})
+	assert.Equal(t, "rollout-linter", canary["stableService"])
+	assert.Equal(t, "rollout-linter-canary", canary["canaryService"])
+	steps := canary["steps"].([]interface{})
+	require.Equal(t, len(steps), 2)
+	assert.Equal(t, float64(20), steps[0].(map[string]interface{})["setWeight"])
+	assert.Equal(t, "10m", steps[1].(map[string]interface{})["pause"].(map[string]interface{})["duration"])
+
+	// The canary Service only selects the application Pods, as Argo Rollouts manages the selector of the new version
+	canaryService := renderK8SServiceCanaryServiceWithSetValues(t, options.SetValues)
+	assert.Equal(t, "service-linter-canary", canaryService.Name)
+	_, hasDeploymentType := canaryService.Spec.Selector["gruntwork.io/deployment-type"]
+	assert.False(t, hasDeploymentType)
+	assert.Equal(t, "linter", canaryService.Spec.Selector["app.kubernetes.io/name"])
+}
+
+// Test that the blueGreen strategy sets the active and preview Services automatically
+func TestK8SServiceRolloutBlueGreenStrategy(t *testing.T) {
+	t.Parallel()
+
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	setValues := map[string]string{
+		"rollout.enabled": "true",
+		"rollout.strategy.blueGreen.autoPromotionEnabled": "false",
+	}
+
+	// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values defined.
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+		SetValues:   setValues,
+	}
+	out := helm.RenderTemplate(t, options, helmChartPath, "rollout", []string{"templates/rollout.yaml"})
+
+	// We take the output and render it to a map to validate the strategy, which is specific to the Rollout
+	rendered := map[string]interface{}{}
+	err = yaml.Unmarshal([]byte(out), &rendered)
+	require.NoError(t, err)
+	strategy := rendered["spec"].(map[string]interface{})["strategy"].(map[string]interface{})
+	_, hasCanary := strategy["canary"]
+	assert.False(t, hasCanary)
+	blueGreen := strategy["blueGreen"].(map[string]interface{})
+	assert.Equal(t, "rollout-linter", blueGreen["activeService"])
+	assert.Equal(t, "rollout-linter-preview", blueGreen["previewService"])
+	assert.Equal(t, false, blueGreen["autoPromotionEnabled"])
+
+	previewService := renderK8SServicePreviewServiceWithSetValues(t, setValues)
+	assert.Equal(t, "service-linter-preview", previewService.Name)
+	_, hasDeploymentColor := previewService.Spec.Selector["gruntwork.io/deployment-color"]
+	assert.False(t, hasDeploymentColor)
+}
+
+// Test that the rollout strategy can not set both canary and blueGreen
+func TestK8SServiceRolloutRejectsBothStrategies(t *testing.T) {
+	t.Parallel()
+
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values defined.
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+		SetValues: map[string]string{
+			"rollout.enabled":                                 "true",
+			"rollout.strategy.canary.steps[0].setWeight":      "20",
+			"rollout.strategy.blueGreen.autoPromotionEnabled": "false",
+		},
+	}
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "rollout", []string{"templates/rollout.yaml"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "at most one of canary or blueGreen")
+}
+
+// Test that the AnalysisTemplates are rendered and named after their keys
+func TestK8SServiceRolloutAnalysisTemplates(t *testing.T) {
+	t.Parallel()
+
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values defined.
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+		SetValues: map[string]string{
+			"rollout.enabled": "true",
+			"rollout.analysisTemplates.success-rate.metrics[0].name":             "success-rate",
+			"rollout.analysisTemplates.success-rate.metrics[0].successCondition": "result[0] >= 0.95",
+		},
+	}
+	out := helm.RenderTemplate(t, options, helmChartPath, "rollout", []string{"templates/analysistemplates.yaml"})
+
+	rendered := map[string]interface{}{}
+	err = yaml.Unmarshal([]byte(out), &rendered)
+	require.NoError(t, err)
+	assert.Equal(t, "AnalysisTemplate", rendered["kind"])
+	assert.Equal(t, "success-rate", rendered["metadata"].(map[string]interface{})["name"])
+	metrics := rendered["spec"].(map[string]interface{})["metrics"].([]interface{})
+	require.Equal(t, len(metrics), 1)
+	assert.Equal(t, "result[0] >= 0.95", metrics[0].(map[string]interface{})["successCondition"])
+}
+
+// Test that the autoscalers target the Rollout when it is enabled
+func TestK8SServiceRolloutAutoscalersTargetRollout(t *testing.T) {
+	t.Parallel()
+
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values defined.
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+		SetValues: map[string]string{
+			"rollout.enabled":                 "true",
+			"horizontalPodAutoscaler.enabled": "true",
+			"verticalPodAutoscaler.enabled":   "true",
+		},
+	}
+
+	for _, templateAndRef := range [][]string{
+		{"templates/horizontalpodautoscaler.yaml", "scaleTargetRef"},
+		{"templates/verticalpodautoscaler.yaml", "targetRef"},
+	} {
+		out := helm.RenderTemplate(t, options, helmChartPath, "rollout", []string{templateAndRef[0]})
+
+		rendered := map[string]interface{}{}
+		err = yaml.Unmarshal([]byte(out), &rendered)
+		require.NoError(t, err)
+		targetRef := rendered["spec"].(map[string]interface{})[templateAndRef[1]].(map[string]interface{})
+		assert.Equal(t, "argoproj.io/v1alpha1", targetRef["apiVersion"])
+		assert.Equal(t, "Rollout", targetRef["kind"])
+		assert.Equal(t, "rollout-linter", targetRef["name"])
+	}
+}
+
+// Test that the rollout can not be combined with the canary Deployment
+func TestK8SServiceRolloutRejectsCanary(t *testing.T) {
+	t.Parallel()
+
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values defined.
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+		SetValues: map[string]string{
+			"rollout.enabled":                  "true",
+			"canary.enabled":                   "true",
+			"canary.containerImage.repository": "nginx",
+			"canary.containerImage.tag":        "1.17.0",
+		},
+	}
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "rollout", []string{"templates/canarydeployment.yaml"})
+	require.Error(t, err)
+	assert.True(t, strings.Contains(err.Error(), "canary can not be used together with rollout"))
+}
@@ -0,0 +1,173 @@
+//go:build all || tpl
+// +build all tpl
+
+// NOTE: We use build flags to differentiate between template tests and integration tests so that you can conveniently
+// run just the template tests. See the test README for more information.
+
+package test
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/helm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Test that secrets entries that only reference an existing Secret do not render a Secret resource.
+func TestK8SServiceSecretsWithoutDataDoesNotRender(t *testing.T) {
+	t.Parallel()
+
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+		SetValues: map[string]string{
+			"secrets.dbpassword.as": "envFrom",
+		},
+	}
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "secrets", []string{"templates/secrets.yaml"})
+	require.Error(t, err)
+}
+
+// Test that setting data and stringData on a secrets entry renders an Opaque Secret named after the key, that is
+// wired into the Pod using the existing injection settings.
+func TestK8SServiceSecretsWithDataRendersOpaqueSecret(t *testing.T) {
+	t.Parallel()
+
+	setValues := map[string]string{
+		"secrets.dbpassword.as":                        "environment",
+		"secrets.dbpassword.items.password.envVarName": "DB_PASSWORD",
+		"secrets.dbpassword.data.password":             "aHVudGVyMg==",
+		"secrets.dbpassword.stringData.username":       "admin",
+	}
+	secrets := renderK8SServiceSecretsWithSetValues(t, setValues)
+	require.Equal(t, len(secrets), 1)
+	secret := secrets[0]
+	assert.Equal(t, secret.Name, "dbpassword")
+	assert.Equal(t, secret.Type, corev1.SecretTypeOpaque)
+	assert.Equal(t, secret.Data, map[string][]byte{"password": []byte("hunter2")})
+	assert.Equal(t, secret.StringData, map[string]string{"username": "admin"})
+	assert.NotContains(t, secret.Annotations, "helm.sh/resource-policy")
+
+	deployment := renderK8SServiceDeploymentWithSetValues(t, setValues)
+	appContainer := deployment.Spec.Template.Spec.Containers[0]
+	require.Equal(t, len(appContainer.Env), 1)
+	assert.Equal(t, appContainer.Env[0].Name, "DB_PASSWORD")
+	assert.Equal(t, appContainer.Env[0].ValueFrom.SecretKeyRef.Name, "dbpassword")
+	assert.Equal(t, appContainer.Env[0].ValueFrom.SecretKeyRef.Key, "password")
+}
+
+// Test that a Secret in the keepExisting mode is rendered with the keep resource policy when it does not exist yet.
+// NOTE: helm template does not talk to the cluster, so lookup never finds an existing Secret here.
+func TestK8SServiceSecretsKeepExistingAddsKeepResourcePolicy(t *testing.T) {
+	t.Parallel()
+
+	secrets := renderK8SServiceSecretsWithSetValues(
+		t,
+		map[string]string{
+			"secrets.apitoken.as":               "envFrom",
+			"secrets.apitoken.keepExisting":     "true",
+			"secrets.apitoken.stringData.TOKEN": "changeme",
+		},
+	)
+	require.Equal(t, len(secrets), 1)
+	assert.Equal(t, secrets[0].Annotations["helm.sh/resource-policy"], "keep")
+	assert.Equal(t, secrets[0].StringData, map[string]string{"TOKEN": "changeme"})
+}
+
+// Test that the checksum annotations cover Secrets created by the chart, except for those in the keepExisting mode.
+func TestK8SServiceConfigChecksumAnnotationsTrackSecretData(t *testing.T) {
+	t.Parallel()
+
+	deployment := renderK8SServiceDeploymentWithSetValues(
+		t,
+		map[string]string{
+			"configChecksumAnnotations":              "true",
+			"secrets.dbpassword.as":                  "envFrom",
+			"secrets.dbpassword.stringData.PASSWORD": "hunter2",
+			"secrets.apitoken.as":                    "envFrom",
+			"secrets.apitoken.keepExisting":          "true",
+			"secrets.apitoken.stringData.TOKEN":      "changeme",
+		},
+	)
+	annotations := deployment.Spec.Template.Annotations
+	require.Equal(t, len(annotations), 1)
+	assert.Len(t, annotations["checksum/secret-dbpassword"], 64)
+
+	updatedDeployment := renderK8SServiceDeploymentWithSetValues(
+		t,
+		map[string]string{
+			"configChecksumAnnotations":              "true",
+			"secrets.dbpassword.as":                  "envFrom",
+			"secrets.dbpassword.stringData.PASSWORD": "correct-horse-battery-staple",
+		},
+	)
+	assert.NotEqual(t, updatedDeployment.Spec.Template.Annotations["checksum/secret-dbpassword"], annotations["checksum/secret-dbpassword"])
+}
+
+// Test that enabling imageCredentials renders a dockerconfigjson Secret and adds it to the imagePullSecrets of the Pod
+// and the ServiceAccount, alongside the Secrets listed in imagePullSecrets.
+func TestK8SServiceImageCredentialsRendersDockerConfigSecret(t *testing.T) {
+	t.Parallel()
+
+	setValues := map[string]string{
+		"imageCredentials.enabled":  "true",
+		"imageCredentials.registry": "registry.example.com",
+		"imageCredentials.username": "deploy",
+		"imageCredentials.password": "hunter2",
+		"imageCredentials.email":    "deploy@example.com",
+		"imagePullSecrets[0]":       "existing-registry",
+		"serviceAccount.create":     "true",
+		"serviceAccount.name":       "linter",
+	}
+
+	secret := renderK8SServiceImagePullSecretWithSetValues(t, setValues)
+	assert.Equal(t, secret.Name, "imagepullsecret-linter-registry")
+	assert.Equal(t, secret.Type, corev1.SecretTypeDockerConfigJson)
+
+	var dockerConfig map[string]map[string]map[string]string
+	require.NoError(t, json.Unmarshal(secret.Data[corev1.DockerConfigJsonKey], &dockerConfig))
+	assert.Equal(t, dockerConfig["auths"]["registry.example.com"], map[string]string{
+		"username": "deploy",
+		"password": "hunter2",
+		"email":    "deploy@example.com",
+		"auth":     "ZGVwbG95Omh1bnRlcjI=",
+	})
+
+	// The release name is part of the default Secret name, so we override it to match the release used by the other
+	// render helpers.
+	setValues["imageCredentials.name"] = "linter-registry"
+	expectedImagePullSecrets := []corev1.LocalObjectReference{
+		{Name: "existing-registry"},
+		{Name: "linter-registry"},
+	}
+
+	deployment := renderK8SServiceDeploymentWithSetValues(t, setValues)
+	assert.Equal(t, deployment.Spec.Template.Spec.ImagePullSecrets, expectedImagePullSecrets)
+
+	serviceAccount := renderK8SServiceAccountWithSetValues(t, setValues)
+	assert.Equal(t, serviceAccount.ImagePullSecrets, expectedImagePullSecrets)
+}
+
+// Test that imageCredentials fails to render without the registry credentials.
+func TestK8SServiceImageCredentialsRequiresCredentials(t *testing.T) {
+	t.Parallel()
+
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+		SetValues: map[string]string{
+			"imageCredentials.enabled":  "true",
+			"imageCredentials.registry": "registry.example.com",
+		},
+	}
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "imagepullsecret", []string{"templates/imagepullsecret.yaml"})
+	require.Error(t, err)
+}
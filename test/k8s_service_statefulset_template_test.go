@@ -0,0 +1,175 @@
+//go:build all || tpl
+// +build all tpl
+
+// NOTE: We use build flags to differentiate between template tests and integration tests so that you can conveniently
+// run just the template tests. See the test README for more information.
+
+package test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/helm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Test that the default workloadType does not render the StatefulSet or the headless Service
+func TestK8SServiceStatefulSetNotRenderedByDefault(t *testing.T) {
+	t.Parallel()
+
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values defined.
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+	}
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "statefulset", []string{"templates/statefulset.yaml"})
+	require.Error(t, err)
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "headlessservice", []string{"templates/headlessservice.yaml"})
+	require.Error(t, err)
+}
+
+// Test that setting workloadType to StatefulSet does not render the Deployment
+func TestK8SServiceStatefulSetDoesNotRenderDeployment(t *testing.T) {
+	t.Parallel()
+
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values defined.
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+		SetValues:   map[string]string{"workloadType": "StatefulSet"},
+	}
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "deployment", []string{"templates/deployment.yaml"})
+	require.Error(t, err)
+}
+
+// Test that an unknown workloadType fails to render
+func TestK8SServiceUnknownWorkloadTypeFails(t *testing.T) {
+	t.Parallel()
+
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values defined.
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+		SetValues:   map[string]string{"workloadType": "ReplicaSet"},
+	}
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "all", []string{})
+	require.Error(t, err)
+}
+
+// Test that the StatefulSet reuses the pod template of the Deployment and sets the StatefulSet specific fields
+func TestK8SServiceStatefulSetRendersControllerSettings(t *testing.T) {
+	t.Parallel()
+
+	statefulset := renderK8SServiceStatefulSetWithSetValues(
+		t,
+		map[string]string{
+			"workloadType":                    "StatefulSet",
+			"replicaCount":                    "3",
+			"envVars.DB_HOST":                 "mysql.default.svc.cluster.local",
+			"statefulSet.podManagementPolicy": "Parallel",
+			"statefulSet.updateStrategy.rollingUpdate.partition": "2",
+		},
+	)
+
+	assert.Equal(t, int32(3), *statefulset.Spec.Replicas)
+	assert.Equal(t, "statefulset-linter-headless", statefulset.Spec.ServiceName)
+	assert.Equal(t, appsv1.ParallelPodManagement, statefulset.Spec.PodManagementPolicy)
+	assert.Equal(t, appsv1.RollingUpdateStatefulSetStrategyType, statefulset.Spec.UpdateStrategy.Type)
+	require.NotNil(t, statefulset.Spec.UpdateStrategy.RollingUpdate)
+	assert.Equal(t, int32(2), *statefulset.Spec.UpdateStrategy.RollingUpdate.Partition)
+
+	// Verify that the container spec is the same as the one rendered for the Deployment
+	renderedPodContainers := statefulset.Spec.Template.Spec.Containers
+	require.Equal(t, len(renderedPodContainers), 1)
+	appContainer := renderedPodContainers[0]
+	assert.Equal(t, "nginx:stable", appContainer.Image)
+	require.Equal(t, len(appContainer.Env), 1)
+	assert.Equal(t, "DB_HOST", appContainer.Env[0].Name)
+	assert.Equal(t, "mysql.default.svc.cluster.local", appContainer.Env[0].Value)
+}
+
+// Test that volumeClaimTemplates render as claim templates on the StatefulSet and are mounted into the main container
+func TestK8SServiceStatefulSetVolumeClaimTemplates(t *testing.T) {
+	t.Parallel()
+
+	statefulset := renderK8SServiceStatefulSetWithSetValues(
+		t,
+		map[string]string{
+			"workloadType": "StatefulSet",
+			"statefulSet.volumeClaimTemplates.data.mountPath":        "/var/lib/data",
+			"statefulSet.volumeClaimTemplates.data.size":             "10Gi",
+			"statefulSet.volumeClaimTemplates.data.storageClassName": "gp2",
+		},
+	)
+
+	claimTemplates := statefulset.Spec.VolumeClaimTemplates
+	require.Equal(t, len(claimTemplates), 1)
+	claimTemplate := claimTemplates[0]
+	assert.Equal(t, "data", claimTemplate.Name)
+	assert.Equal(t, []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}, claimTemplate.Spec.AccessModes)
+	require.NotNil(t, claimTemplate.Spec.StorageClassName)
+	assert.Equal(t, "gp2", *claimTemplate.Spec.StorageClassName)
+	storage := claimTemplate.Spec.Resources.Requests[corev1.ResourceStorage]
+	assert.Equal(t, "10Gi", storage.String())
+
+	// Verify that the claim is mounted, and that it is not declared as a pod volume since the controller injects it
+	renderedPodContainers := statefulset.Spec.Template.Spec.Containers
+	require.Equal(t, len(renderedPodContainers), 1)
+	mounts := renderedPodContainers[0].VolumeMounts
+	require.Equal(t, len(mounts), 1)
+	assert.Equal(t, "data", mounts[0].Name)
+	assert.Equal(t, "/var/lib/data", mounts[0].MountPath)
+	assert.Equal(t, len(statefulset.Spec.Template.Spec.Volumes), 0)
+}
+
+// Test that the headless Service is rendered with the governing service name and selects the application Pods
+func TestK8SServiceStatefulSetHeadlessService(t *testing.T) {
+	t.Parallel()
+
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values defined.
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+		SetValues:   map[string]string{"workloadType": "StatefulSet"},
+	}
+	out := helm.RenderTemplate(t, options, helmChartPath, "headlessservice", []string{"templates/headlessservice.yaml"})
+
+	var service corev1.Service
+	helm.UnmarshalK8SYaml(t, out, &service)
+	assert.Equal(t, "headlessservice-linter-headless", service.Name)
+	assert.Equal(t, corev1.ClusterIPNone, service.Spec.ClusterIP)
+	assert.Equal(t, "linter", service.Spec.Selector["app.kubernetes.io/name"])
+	require.Equal(t, len(service.Spec.Ports), 1)
+	assert.Equal(t, "app", service.Spec.Ports[0].Name)
+}
+
+// Test that canary deployments are rejected for StatefulSets
+func TestK8SServiceStatefulSetRejectsCanary(t *testing.T) {
+	t.Parallel()
+
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values defined.
+	options := &helm.Options{
+		ValuesFiles: []string{
+			filepath.Join("..", "charts", "k8s-service", "linter_values.yaml"),
+			filepath.Join("fixtures", "canary_deployment_values.yaml"),
+		},
+		SetValues: map[string]string{"workloadType": "StatefulSet"},
+	}
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "canary", []string{"templates/canarydeployment.yaml"})
+	require.Error(t, err)
+}
@@ -8,11 +8,13 @@ package test
 
 import (
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/gruntwork-io/terratest/modules/helm"
 	"github.com/stretchr/testify/require"
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	extv1beta1 "k8s.io/api/extensions/v1beta1"
 	networkingv1 "k8s.io/api/networking/v1"
@@ -38,6 +40,28 @@ func renderK8SServiceDeploymentWithSetValues(t *testing.T, setValues map[string]
 	return deployment
 }
 
+func renderK8SServiceBlueGreenDeploymentsWithSetValues(t *testing.T, setValues map[string]string) []appsv1.Deployment {
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values defined.
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+		SetValues:   setValues,
+	}
+	// Render just the deployment resources
+	out := helm.RenderTemplate(t, options, helmChartPath, "bluegreen", []string{"templates/deployment.yaml"})
+
+	// Parse each of the deployments and return them
+	deployments := []appsv1.Deployment{}
+	for _, document := range splitYamlDocuments(out) {
+		var deployment appsv1.Deployment
+		helm.UnmarshalK8SYaml(t, document, &deployment)
+		deployments = append(deployments, deployment)
+	}
+	return deployments
+}
+
 func renderK8SServiceCanaryDeploymentWithSetValues(t *testing.T, setValues map[string]string) appsv1.Deployment {
 	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
 	require.NoError(t, err)
@@ -56,6 +80,96 @@ func renderK8SServiceCanaryDeploymentWithSetValues(t *testing.T, setValues map[s
 	return canarydeployment
 }
 
+func renderK8SServiceStatefulSetWithSetValues(t *testing.T, setValues map[string]string) appsv1.StatefulSet {
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values defined.
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+		SetValues:   setValues,
+	}
+	// Render just the statefulset resource
+	out := helm.RenderTemplate(t, options, helmChartPath, "statefulset", []string{"templates/statefulset.yaml"})
+
+	// Parse the statefulset and return it
+	var statefulset appsv1.StatefulSet
+	helm.UnmarshalK8SYaml(t, out, &statefulset)
+	return statefulset
+}
+
+func renderK8SServiceDaemonSetWithSetValues(t *testing.T, setValues map[string]string) appsv1.DaemonSet {
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values defined.
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+		SetValues:   setValues,
+	}
+	// Render just the daemonset resource
+	out := helm.RenderTemplate(t, options, helmChartPath, "daemonset", []string{"templates/daemonset.yaml"})
+
+	// Parse the daemonset and return it
+	var daemonset appsv1.DaemonSet
+	helm.UnmarshalK8SYaml(t, out, &daemonset)
+	return daemonset
+}
+
+func renderK8SServiceJobWithSetValues(t *testing.T, setValues map[string]string) batchv1.Job {
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values defined.
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+		SetValues:   setValues,
+	}
+	// Render just the jobs resource
+	out := helm.RenderTemplate(t, options, helmChartPath, "jobs", []string{"templates/jobs.yaml"})
+
+	// Parse the job and return it
+	var job batchv1.Job
+	helm.UnmarshalK8SYaml(t, out, &job)
+	return job
+}
+
+func renderK8SServiceMigrationsJobWithSetValues(t *testing.T, setValues map[string]string) batchv1.Job {
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values defined.
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+		SetValues:   setValues,
+	}
+	// Render just the migrations resource
+	out := helm.RenderTemplate(t, options, helmChartPath, "migrations", []string{"templates/migrations.yaml"})
+
+	// Parse the job and return it
+	var job batchv1.Job
+	helm.UnmarshalK8SYaml(t, out, &job)
+	return job
+}
+
+func renderK8SServiceCronJobWithSetValues(t *testing.T, setValues map[string]string) batchv1.CronJob {
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values defined.
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+		SetValues:   setValues,
+	}
+	// Render just the jobs resource
+	out := helm.RenderTemplate(t, options, helmChartPath, "jobs", []string{"templates/jobs.yaml"})
+
+	// Parse the cronjob and return it
+	var cronjob batchv1.CronJob
+	helm.UnmarshalK8SYaml(t, out, &cronjob)
+	return cronjob
+}
+
 func renderK8SServiceIngressWithSetValues(t *testing.T, setValues map[string]string) networkingv1.Ingress {
 	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
 	require.NoError(t, err)
@@ -74,6 +188,48 @@ func renderK8SServiceIngressWithSetValues(t *testing.T, setValues map[string]str
 	return ingress
 }
 
+// renderK8SServiceIngressesWithSetValues renders the additional Ingress resources configured with the ingresses input
+// value, which are rendered as multiple documents of the same template.
+func renderK8SServiceIngressesWithSetValues(t *testing.T, setValues map[string]string) []networkingv1.Ingress {
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values defined.
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+		SetValues:   setValues,
+	}
+	// Render just the ingresses resources
+	out := helm.RenderTemplate(t, options, helmChartPath, "ingress", []string{"templates/ingresses.yaml"})
+
+	// Parse each ingress and return them
+	ingresses := []networkingv1.Ingress{}
+	for _, document := range splitYamlDocuments(out) {
+		var ingress networkingv1.Ingress
+		helm.UnmarshalK8SYaml(t, document, &ingress)
+		ingresses = append(ingresses, ingress)
+	}
+	return ingresses
+}
+
+func renderK8SServiceCanaryIngressWithSetValues(t *testing.T, setValues map[string]string) networkingv1.Ingress {
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values defined.
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+		SetValues:   setValues,
+	}
+	// Render just the canary ingress resource
+	out := helm.RenderTemplate(t, options, helmChartPath, "ingress", []string{"templates/canaryingress.yaml"})
+
+	// Parse the canary ingress and return it
+	var ingress networkingv1.Ingress
+	helm.UnmarshalK8SYaml(t, out, &ingress)
+	return ingress
+}
+
 func renderK8SServiceIngressWithValuesFile(t *testing.T, valuesFilePath string) networkingv1.Ingress {
 	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
 	require.NoError(t, err)
@@ -165,3 +321,153 @@ func renderK8SServiceWithSetValues(t *testing.T, setValues map[string]string) co
 	helm.UnmarshalK8SYaml(t, out, &service)
 	return service
 }
+
+func renderK8SServiceCanaryServiceWithSetValues(t *testing.T, setValues map[string]string) corev1.Service {
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values defined.
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+		SetValues:   setValues,
+	}
+	// Render just the canary service resource
+	out := helm.RenderTemplate(t, options, helmChartPath, "service", []string{"templates/canaryservice.yaml"})
+
+	// Parse the canary service and return it
+	var service corev1.Service
+	helm.UnmarshalK8SYaml(t, out, &service)
+	return service
+}
+
+func renderK8SServicePreviewServiceWithSetValues(t *testing.T, setValues map[string]string) corev1.Service {
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values defined.
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+		SetValues:   setValues,
+	}
+	// Render just the preview service resource
+	out := helm.RenderTemplate(t, options, helmChartPath, "service", []string{"templates/previewservice.yaml"})
+
+	// Parse the preview service and return it
+	var service corev1.Service
+	helm.UnmarshalK8SYaml(t, out, &service)
+	return service
+}
+
+// splitYamlDocuments splits the output of a template that renders multiple resources into the individual documents,
+// dropping any empty documents.
+func splitYamlDocuments(out string) []string {
+	documents := []string{}
+	for _, document := range strings.Split(out, "\n---\n") {
+		if strings.TrimSpace(strings.TrimPrefix(document, "---")) != "" {
+			documents = append(documents, document)
+		}
+	}
+	return documents
+}
+
+func renderK8SServiceNetworkPolicyWithSetValues(t *testing.T, setValues map[string]string) networkingv1.NetworkPolicy {
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values defined.
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+		SetValues:   setValues,
+	}
+	// Render just the network policy resource
+	out := helm.RenderTemplate(t, options, helmChartPath, "networkpolicy", []string{"templates/networkpolicy.yaml"})
+
+	// Parse the network policy and return it
+	var networkPolicy networkingv1.NetworkPolicy
+	helm.UnmarshalK8SYaml(t, out, &networkPolicy)
+	return networkPolicy
+}
+
+func renderK8SServiceConfigMapsWithSetValues(t *testing.T, setValues map[string]string) []corev1.ConfigMap {
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values defined.
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+		SetValues:   setValues,
+	}
+	// Render just the config map resources
+	out := helm.RenderTemplate(t, options, helmChartPath, "configmaps", []string{"templates/configmaps.yaml"})
+
+	// Parse each of the config maps and return them
+	configMaps := []corev1.ConfigMap{}
+	for _, document := range splitYamlDocuments(out) {
+		var configMap corev1.ConfigMap
+		helm.UnmarshalK8SYaml(t, document, &configMap)
+		configMaps = append(configMaps, configMap)
+	}
+	return configMaps
+}
+
+func renderK8SServiceSecretsWithSetValues(t *testing.T, setValues map[string]string) []corev1.Secret {
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values defined.
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+		SetValues:   setValues,
+	}
+	// Render just the secret resources
+	out := helm.RenderTemplate(t, options, helmChartPath, "secrets", []string{"templates/secrets.yaml"})
+
+	// Parse each of the secrets and return them
+	secrets := []corev1.Secret{}
+	for _, document := range splitYamlDocuments(out) {
+		var secret corev1.Secret
+		helm.UnmarshalK8SYaml(t, document, &secret)
+		secrets = append(secrets, secret)
+	}
+	return secrets
+}
+
+func renderK8SServiceImagePullSecretWithSetValues(t *testing.T, setValues map[string]string) corev1.Secret {
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values defined.
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+		SetValues:   setValues,
+	}
+	// Render just the image pull secret resource
+	out := helm.RenderTemplate(t, options, helmChartPath, "imagepullsecret", []string{"templates/imagepullsecret.yaml"})
+
+	// Parse the image pull secret and return it
+	var secret corev1.Secret
+	helm.UnmarshalK8SYaml(t, out, &secret)
+	return secret
+}
+
+func renderK8SServicePersistentVolumeClaimsWithSetValues(t *testing.T, setValues map[string]string) []corev1.PersistentVolumeClaim {
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values defined.
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+		SetValues:   setValues,
+	}
+	// Render just the persistent volume claim resources
+	out := helm.RenderTemplate(t, options, helmChartPath, "pvc", []string{"templates/persistentvolumeclaims.yaml"})
+
+	// Parse each of the persistent volume claims and return them
+	claims := []corev1.PersistentVolumeClaim{}
+	for _, document := range splitYamlDocuments(out) {
+		var claim corev1.PersistentVolumeClaim
+		helm.UnmarshalK8SYaml(t, document, &claim)
+		claims = append(claims, claim)
+	}
+	return claims
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	policyv1beta1 "k8s.io/api/policy/v1beta1"
 )
 
@@ -535,6 +536,178 @@ func TestK8SServiceIngressWithHostsAdditionalPathsHigherPriorityNoServiceName(t
 	assert.Equal(t, secondPath.Backend.Service.Port.Name, "app")
 }
 
+// Test that ingress.rules renders each host with its own paths, in the configured order, and that the flat form is
+// ignored when rules are set
+func TestK8SServiceIngressRulesPerHostOrdering(t *testing.T) {
+	t.Parallel()
+
+	ingress := renderK8SServiceIngressWithSetValues(
+		t,
+		map[string]string{
+			"ingress.enabled":                       "true",
+			"ingress.servicePort":                   "app",
+			"ingress.path":                          "/ignored",
+			"ingress.hosts[0]":                      "ignored.example.com",
+			"ingress.rules[0].host":                 "api.example.com",
+			"ingress.rules[0].paths[0].path":        "/v1/admin",
+			"ingress.rules[0].paths[0].pathType":    "Exact",
+			"ingress.rules[0].paths[0].serviceName": "admin",
+			"ingress.rules[0].paths[0].servicePort": "8080",
+			"ingress.rules[0].paths[1].path":        "/v1",
+			"ingress.rules[0].paths[1].pathType":    "Prefix",
+			"ingress.rules[1].host":                 "admin.example.com",
+			"ingress.rules[1].paths[0].path":        "/",
+			"ingress.rules[1].paths[0].pathType":    "Prefix",
+			"ingress.rules[1].paths[0].serviceName": "admin",
+			"ingress.rules[1].paths[0].servicePort": "8080",
+		},
+	)
+	require.Equal(t, 2, len(ingress.Spec.Rules))
+
+	apiRule := ingress.Spec.Rules[0]
+	assert.Equal(t, "api.example.com", apiRule.Host)
+	apiPaths := apiRule.HTTP.Paths
+	require.Equal(t, 2, len(apiPaths))
+
+	// The first path should be the admin path, as it is listed first
+	assert.Equal(t, "/v1/admin", apiPaths[0].Path)
+	assert.Equal(t, networkingv1.PathTypeExact, *apiPaths[0].PathType)
+	assert.Equal(t, "admin", apiPaths[0].Backend.Service.Name)
+	assert.Equal(t, int32(8080), apiPaths[0].Backend.Service.Port.Number)
+
+	// The second path should default to the application service and the ingress servicePort
+	assert.Equal(t, "/v1", apiPaths[1].Path)
+	assert.Equal(t, networkingv1.PathTypePrefix, *apiPaths[1].PathType)
+	assert.Equal(t, "ingress-linter", apiPaths[1].Backend.Service.Name)
+	assert.Equal(t, "app", apiPaths[1].Backend.Service.Port.Name)
+
+	adminRule := ingress.Spec.Rules[1]
+	assert.Equal(t, "admin.example.com", adminRule.Host)
+	adminPaths := adminRule.HTTP.Paths
+	require.Equal(t, 1, len(adminPaths))
+	assert.Equal(t, "/", adminPaths[0].Path)
+	assert.Equal(t, "admin", adminPaths[0].Backend.Service.Name)
+	assert.Equal(t, int32(8080), adminPaths[0].Backend.Service.Port.Number)
+}
+
+// Test that a rule without a host matches all hosts
+func TestK8SServiceIngressRulesWithoutHost(t *testing.T) {
+	t.Parallel()
+
+	ingress := renderK8SServiceIngressWithSetValues(
+		t,
+		map[string]string{
+			"ingress.enabled":                    "true",
+			"ingress.servicePort":                "80",
+			"ingress.rules[0].paths[0].path":     "/",
+			"ingress.rules[0].paths[0].pathType": "Prefix",
+		},
+	)
+	require.Equal(t, 1, len(ingress.Spec.Rules))
+	rule := ingress.Spec.Rules[0]
+	assert.Equal(t, "", rule.Host)
+	require.Equal(t, 1, len(rule.HTTP.Paths))
+	assert.Equal(t, "ingress-linter", rule.HTTP.Paths[0].Backend.Service.Name)
+	assert.Equal(t, int32(80), rule.HTTP.Paths[0].Backend.Service.Port.Number)
+}
+
+// Test that no additional Ingress is rendered by default
+func TestK8SServiceIngressesNotRenderedByDefault(t *testing.T) {
+	t.Parallel()
+
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values defined.
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+	}
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "ingress", []string{"templates/ingresses.yaml"})
+	require.Error(t, err)
+}
+
+// Test that each entry of ingresses renders its own Ingress, with independent class, annotations, TLS and rules
+func TestK8SServiceIngressesRendersIngressPerEntry(t *testing.T) {
+	t.Parallel()
+
+	ingresses := renderK8SServiceIngressesWithSetValues(
+		t,
+		map[string]string{
+			"ingresses.internal.ingressClassName":                                   "alb",
+			"ingresses.internal.annotations.alb\\.ingress\\.kubernetes\\.io/scheme": "internal",
+			"ingresses.internal.hosts[0]":                                           "chart-example.internal",
+			"ingresses.internal.path":                                               "/",
+			"ingresses.internal.pathType":                                           "Prefix",
+			"ingresses.internal.servicePort":                                        "app",
+			"ingresses.public.ingressClassName":                                     "nginx",
+			"ingresses.public.annotations.alb\\.ingress\\.kubernetes\\.io/scheme":   "internet-facing",
+			"ingresses.public.tls[0].secretName":                                    "chart-example-tls",
+			"ingresses.public.tls[0].hosts[0]":                                      "chart-example.com",
+			"ingresses.public.rules[0].host":                                        "chart-example.com",
+			"ingresses.public.rules[0].paths[0].path":                               "/api",
+			"ingresses.public.rules[0].paths[0].pathType":                           "Prefix",
+			"ingresses.public.rules[0].paths[0].servicePort":                        "80",
+		},
+	)
+	require.Equal(t, 2, len(ingresses))
+
+	// The ingresses are rendered in the order of their keys
+	internal := ingresses[0]
+	assert.Equal(t, "ingress-linter-internal", internal.Name)
+	assert.Equal(t, "alb", *internal.Spec.IngressClassName)
+	assert.Equal(t, "internal", internal.Annotations["alb.ingress.kubernetes.io/scheme"])
+	assert.Equal(t, 0, len(internal.Spec.TLS))
+	require.Equal(t, 1, len(internal.Spec.Rules))
+	assert.Equal(t, "chart-example.internal", internal.Spec.Rules[0].Host)
+	require.Equal(t, 1, len(internal.Spec.Rules[0].HTTP.Paths))
+	internalPath := internal.Spec.Rules[0].HTTP.Paths[0]
+	assert.Equal(t, "/", internalPath.Path)
+	assert.Equal(t, "ingress-linter", internalPath.Backend.Service.Name)
+	assert.Equal(t, "app", internalPath.Backend.Service.Port.Name)
+
+	public := ingresses[1]
+	assert.Equal(t, "ingress-linter-public", public.Name)
+	assert.Equal(t, "nginx", *public.Spec.IngressClassName)
+	assert.Equal(t, "internet-facing", public.Annotations["alb.ingress.kubernetes.io/scheme"])
+	require.Equal(t, 1, len(public.Spec.TLS))
+	assert.Equal(t, "chart-example-tls", public.Spec.TLS[0].SecretName)
+	assert.Equal(t, []string{"chart-example.com"}, public.Spec.TLS[0].Hosts)
+	require.Equal(t, 1, len(public.Spec.Rules))
+	assert.Equal(t, "chart-example.com", public.Spec.Rules[0].Host)
+	require.Equal(t, 1, len(public.Spec.Rules[0].HTTP.Paths))
+	publicPath := public.Spec.Rules[0].HTTP.Paths[0]
+	assert.Equal(t, "/api", publicPath.Path)
+	assert.Equal(t, "ingress-linter", publicPath.Backend.Service.Name)
+	assert.Equal(t, int32(80), publicPath.Backend.Service.Port.Number)
+}
+
+// Test that the additional Ingresses are rendered independently of the main Ingress
+func TestK8SServiceIngressesWithMainIngress(t *testing.T) {
+	t.Parallel()
+
+	setValues := map[string]string{
+		"ingress.enabled":                "true",
+		"ingress.path":                   "/app",
+		"ingress.pathType":               "Prefix",
+		"ingress.servicePort":            "app",
+		"ingress.hosts[0]":               "chart-example.local",
+		"ingresses.internal.path":        "/",
+		"ingresses.internal.pathType":    "Prefix",
+		"ingresses.internal.servicePort": "80",
+		"ingresses.internal.hosts[0]":    "chart-example.internal",
+	}
+	ingress := renderK8SServiceIngressWithSetValues(t, setValues)
+	assert.Equal(t, "ingress-linter", ingress.Name)
+	require.Equal(t, 1, len(ingress.Spec.Rules))
+	assert.Equal(t, "chart-example.local", ingress.Spec.Rules[0].Host)
+
+	ingresses := renderK8SServiceIngressesWithSetValues(t, setValues)
+	require.Equal(t, 1, len(ingresses))
+	assert.Equal(t, "ingress-linter-internal", ingresses[0].Name)
+	require.Equal(t, 1, len(ingresses[0].Spec.Rules))
+	assert.Equal(t, "chart-example.internal", ingresses[0].Spec.Rules[0].Host)
+}
+
 // Test rendering Managed Certificate
 func TestK8SServiceManagedCertDomainNameAndName(t *testing.T) {
 	t.Parallel()
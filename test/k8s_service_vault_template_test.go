@@ -0,0 +1,155 @@
+//go:build all || tpl
+// +build all tpl
+
+// NOTE: We use build flags to differentiate between template tests and integration tests so that you can conveniently
+// run just the template tests. See the test README for more information.
+
+package test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/gruntwork-io/terratest/modules/helm"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// renderK8SServiceVaultDeploymentWithSetValues renders the given deployment template with the vault_values.yaml fixture,
+// which configures multiple Vault secrets including a template that can not be passed in with SetValues.
+func renderK8SServiceVaultDeploymentWithSetValues(t *testing.T, templateName string, setValues map[string]string) appsv1.Deployment {
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values defined.
+	options := &helm.Options{
+		ValuesFiles: []string{
+			filepath.Join("..", "charts", "k8s-service", "linter_values.yaml"),
+			filepath.Join("fixtures", "vault_values.yaml"),
+		},
+		SetValues: setValues,
+	}
+	out := helm.RenderTemplate(t, options, helmChartPath, "vault", []string{templateName})
+
+	var deployment appsv1.Deployment
+	helm.UnmarshalK8SYaml(t, out, &deployment)
+	return deployment
+}
+
+// Test that the Vault Agent injector annotations are not added by default
+func TestK8SServiceVaultDisabledByDefault(t *testing.T) {
+	t.Parallel()
+
+	deployment := renderK8SServiceDeploymentWithSetValues(t, map[string]string{})
+	assert.Equal(t, len(deployment.Spec.Template.Annotations), 0)
+}
+
+// Test that a multi-secret vault config renders the exact set of Vault Agent injector annotations on both the main and
+// canary Pods, merged with the podAnnotations.
+func TestK8SServiceVaultAddsAnnotationsToMainAndCanaryPods(t *testing.T) {
+	t.Parallel()
+
+	setValues := map[string]string{
+		"podAnnotations.foo":               "bar",
+		"canary.enabled":                   "true",
+		"canary.containerImage.repository": "nginx",
+		"canary.containerImage.tag":        "1.16.0",
+	}
+	expectedAnnotations := map[string]string{
+		"foo":                                              "bar",
+		"vault.hashicorp.com/agent-inject":                 "true",
+		"vault.hashicorp.com/role":                         "my-app",
+		"vault.hashicorp.com/auth-path":                    "auth/kubernetes-prod",
+		"vault.hashicorp.com/agent-init-first":             "true",
+		"vault.hashicorp.com/agent-pre-populate-only":      "true",
+		"vault.hashicorp.com/agent-inject-secret-db-creds": "database/creds/my-app",
+		"vault.hashicorp.com/agent-inject-template-db-creds": "{{- with secret \"database/creds/my-app\" -}}\n" +
+			"postgres://{{ .Data.username }}:{{ .Data.password }}@postgres:5432/app\n" +
+			"{{- end -}}\n",
+		"vault.hashicorp.com/agent-inject-secret-api-key": "secret/data/my-app/api",
+	}
+
+	deployment := renderK8SServiceVaultDeploymentWithSetValues(t, "templates/deployment.yaml", setValues)
+	assert.Equal(t, expectedAnnotations, deployment.Spec.Template.Annotations)
+
+	canaryDeployment := renderK8SServiceVaultDeploymentWithSetValues(t, "templates/canarydeployment.yaml", setValues)
+	assert.Equal(t, expectedAnnotations, canaryDeployment.Spec.Template.Annotations)
+}
+
+// Test that the optional annotations are left out when they are not configured.
+func TestK8SServiceVaultMinimalAnnotations(t *testing.T) {
+	t.Parallel()
+
+	deployment := renderK8SServiceDeploymentWithSetValues(
+		t,
+		map[string]string{
+			"vault.enabled":           "true",
+			"vault.role":              "my-app",
+			"vault.secrets.api.path":  "secret/data/my-app/api",
+			"vault.secrets.cert.path": "pki/issue/my-app",
+		},
+	)
+	assert.Equal(
+		t,
+		map[string]string{
+			"vault.hashicorp.com/agent-inject":             "true",
+			"vault.hashicorp.com/role":                     "my-app",
+			"vault.hashicorp.com/agent-inject-secret-api":  "secret/data/my-app/api",
+			"vault.hashicorp.com/agent-inject-secret-cert": "pki/issue/my-app",
+		},
+		deployment.Spec.Template.Annotations,
+	)
+	assert.Equal(t, len(deployment.Spec.Template.Spec.Containers[0].Env), 0)
+}
+
+// Test that setting envVarName on a Vault secret adds an environment variable pointing at the rendered file.
+func TestK8SServiceVaultAddsEnvVarsForSecretFiles(t *testing.T) {
+	t.Parallel()
+
+	deployment := renderK8SServiceVaultDeploymentWithSetValues(t, "templates/deployment.yaml", map[string]string{})
+	appContainer := deployment.Spec.Template.Spec.Containers[0]
+	assert.Equal(t, []corev1.EnvVar{{Name: "DB_CREDS_FILE", Value: "/vault/secrets/db-creds"}}, appContainer.Env)
+}
+
+// Test that the vault config fails to render without a role or without any secrets.
+func TestK8SServiceVaultRequiresRoleAndSecrets(t *testing.T) {
+	t.Parallel()
+
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name      string
+		setValues map[string]string
+	}{
+		{
+			"missing role",
+			map[string]string{"vault.enabled": "true", "vault.secrets.api.path": "secret/data/my-app/api"},
+		},
+		{
+			"missing secrets",
+			map[string]string{"vault.enabled": "true", "vault.role": "my-app"},
+		},
+		{
+			"missing secret path",
+			map[string]string{"vault.enabled": "true", "vault.role": "my-app", "vault.secrets.api.envVarName": "API_KEY_FILE"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		// Capture range variable to force scope
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			options := &helm.Options{
+				ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+				SetValues:   testCase.setValues,
+			}
+			_, err := helm.RenderTemplateE(t, options, helmChartPath, "deployment", []string{"templates/deployment.yaml"})
+			require.Error(t, err)
+		})
+	}
+}
@@ -7,12 +7,40 @@
 package test
 
 import (
+	"path/filepath"
 	"testing"
 
+	"github.com/ghodss/yaml"
+	"github.com/gruntwork-io/terratest/modules/helm"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
+// renderK8SServiceSecretProviderClassesWithSetValues renders the SecretProviderClass resources and returns them keyed by
+// name as maps, since there are no Go types available for the Secrets Store CSI driver resources in this module.
+func renderK8SServiceSecretProviderClassesWithSetValues(t *testing.T, setValues map[string]string) map[string]map[string]interface{} {
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	// We make sure to pass in the linter_values.yaml values file, which we assume has all the required values defined.
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+		SetValues:   setValues,
+	}
+	out := helm.RenderTemplate(t, options, helmChartPath, "secretproviderclasses", []string{"templates/secretproviderclasses.yaml"})
+
+	secretProviderClasses := map[string]map[string]interface{}{}
+	for _, document := range splitYamlDocuments(out) {
+		rendered := map[string]interface{}{}
+		require.NoError(t, yaml.Unmarshal([]byte(document), &rendered))
+		assert.Equal(t, "secrets-store.csi.x-k8s.io/v1", rendered["apiVersion"])
+		assert.Equal(t, "SecretProviderClass", rendered["kind"])
+		name := rendered["metadata"].(map[string]interface{})["name"].(string)
+		secretProviderClasses[name] = rendered
+	}
+	return secretProviderClasses
+}
+
 func TestK8SServiceDeploymentCheckSecretStoreCSIBlock(t *testing.T) {
 	t.Parallel()
 
@@ -51,3 +79,120 @@ func TestK8SServiceDeploymentCheckSecretStoreCSIBlock(t *testing.T) {
 		"secretProviderClass": "secret-provider-class",
 	})
 }
+
+// Test that the SecretProviderClass is not rendered when the csi secrets only reference an existing SecretProviderClass.
+func TestK8SServiceSecretProviderClassNotRenderedWithoutProvider(t *testing.T) {
+	t.Parallel()
+
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+		SetValues: map[string]string{
+			"secrets.dbsettings.as":                      "csi",
+			"secrets.dbsettings.mountPath":               "/etc/db",
+			"secrets.dbsettings.csi.driver":              "secrets-store.csi.k8s.io",
+			"secrets.dbsettings.csi.secretProviderClass": "secret-provider-class",
+		},
+	}
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "secretproviderclasses", []string{"templates/secretproviderclasses.yaml"})
+	require.Error(t, err)
+}
+
+// Test that setting csi.provider renders a SecretProviderClass referenced by the CSI volume, which syncs the items to a
+// Secret named after the key so that the secretKeyRef environment variables resolve.
+func TestK8SServiceSecretProviderClassRenderedFromValues(t *testing.T) {
+	t.Parallel()
+
+	setValues := map[string]string{
+		"secrets.dbsettings.as":                         "csi",
+		"secrets.dbsettings.mountPath":                  "/etc/db",
+		"secrets.dbsettings.readOnly":                   "true",
+		"secrets.dbsettings.csi.driver":                 "secrets-store.csi.k8s.io",
+		"secrets.dbsettings.csi.provider":               "aws",
+		"secrets.dbsettings.csi.parameters.region":      "us-east-1",
+		"secrets.dbsettings.csi.objects[0].objectName":  "prod/db",
+		"secrets.dbsettings.csi.objects[0].objectType":  "secretsmanager",
+		"secrets.dbsettings.csi.objects[0].objectAlias": "dbpassword",
+		"secrets.dbsettings.items.password.envVarName":  "DB_PASSWORD",
+		"secrets.dbsettings.items.password.objectName":  "dbpassword",
+		"secrets.dbsettings.items.host.envVarName":      "DB_HOST",
+	}
+
+	secretProviderClasses := renderK8SServiceSecretProviderClassesWithSetValues(t, setValues)
+	require.Equal(t, len(secretProviderClasses), 1)
+	spec := secretProviderClasses["dbsettings"]["spec"].(map[string]interface{})
+	assert.Equal(t, "aws", spec["provider"])
+
+	parameters := spec["parameters"].(map[string]interface{})
+	assert.Equal(t, "us-east-1", parameters["region"])
+	var objects []map[string]string
+	require.NoError(t, yaml.Unmarshal([]byte(parameters["objects"].(string)), &objects))
+	assert.Equal(t, []map[string]string{{"objectName": "prod/db", "objectType": "secretsmanager", "objectAlias": "dbpassword"}}, objects)
+
+	assert.Equal(
+		t,
+		[]interface{}{
+			map[string]interface{}{
+				"secretName": "dbsettings",
+				"type":       "Opaque",
+				"data": []interface{}{
+					map[string]interface{}{"key": "host", "objectName": "host"},
+					map[string]interface{}{"key": "password", "objectName": "dbpassword"},
+				},
+			},
+		},
+		spec["secretObjects"],
+	)
+
+	deployment := renderK8SServiceDeploymentWithSetValues(t, setValues)
+	renderedPodSpec := deployment.Spec.Template.Spec
+	require.Equal(t, len(renderedPodSpec.Volumes), 1)
+	assert.Equal(t, renderedPodSpec.Volumes[0].CSI.VolumeAttributes, map[string]string{
+		"secretProviderClass": "dbsettings",
+	})
+	appContainer := renderedPodSpec.Containers[0]
+	require.Equal(t, len(appContainer.Env), 2)
+	for _, env := range appContainer.Env {
+		assert.Equal(t, env.ValueFrom.SecretKeyRef.Name, "dbsettings")
+	}
+}
+
+// Test that an explicit secretObjects list and secretProviderClass name override the defaults derived from the key.
+func TestK8SServiceSecretProviderClassExplicitSecretObjects(t *testing.T) {
+	t.Parallel()
+
+	secretProviderClasses := renderK8SServiceSecretProviderClassesWithSetValues(
+		t,
+		map[string]string{
+			"secrets.dbsettings.as":                                      "csi",
+			"secrets.dbsettings.mountPath":                               "/etc/db",
+			"secrets.dbsettings.csi.driver":                              "secrets-store.csi.k8s.io",
+			"secrets.dbsettings.csi.provider":                            "vault",
+			"secrets.dbsettings.csi.secretProviderClass":                 "vault-db",
+			"secrets.dbsettings.csi.parameters.roleName":                 "app",
+			"secrets.dbsettings.csi.secretObjects[0].secretName":         "db-synced",
+			"secrets.dbsettings.csi.secretObjects[0].type":               "Opaque",
+			"secrets.dbsettings.csi.secretObjects[0].data[0].key":        "password",
+			"secrets.dbsettings.csi.secretObjects[0].data[0].objectName": "db-password",
+		},
+	)
+	require.Equal(t, len(secretProviderClasses), 1)
+	spec := secretProviderClasses["vault-db"]["spec"].(map[string]interface{})
+	assert.Equal(t, "vault", spec["provider"])
+	assert.Equal(t, map[string]interface{}{"roleName": "app"}, spec["parameters"])
+	assert.Equal(
+		t,
+		[]interface{}{
+			map[string]interface{}{
+				"secretName": "db-synced",
+				"type":       "Opaque",
+				"data": []interface{}{
+					map[string]interface{}{"key": "password", "objectName": "db-password"},
+				},
+			},
+		},
+		spec["secretObjects"],
+	)
+}
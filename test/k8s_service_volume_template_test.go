@@ -8,12 +8,15 @@ package test
 
 import (
 	"fmt"
+	"path/filepath"
 	"strconv"
 	"testing"
 
+	"github.com/gruntwork-io/terratest/modules/helm"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 func TestK8SServiceDeploymentAddingScratchVolumes(t *testing.T) {
@@ -84,6 +87,203 @@ func TestK8SServiceDeploymentAddingPersistentVolumes(t *testing.T) {
 	assert.Equal(t, volClaim, volume.PersistentVolumeClaim.ClaimName)
 }
 
+func TestK8SServicePersistentVolumesWithoutSizeDoesNotRenderClaim(t *testing.T) {
+	t.Parallel()
+
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+		SetValues: map[string]string{
+			"persistentVolumes.pv-1.claimName": "claim-1",
+			"persistentVolumes.pv-1.mountPath": "/mnt/path/1",
+		},
+	}
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "pvc", []string{"templates/persistentvolumeclaims.yaml"})
+	require.Error(t, err)
+}
+
+func TestK8SServicePersistentVolumesWithSizeRendersClaim(t *testing.T) {
+	t.Parallel()
+
+	setValues := map[string]string{
+		"persistentVolumes.pv-1.claimName":        "claim-1",
+		"persistentVolumes.pv-1.mountPath":        "/mnt/path/1",
+		"persistentVolumes.pv-1.size":             "10Gi",
+		"persistentVolumes.pv-1.storageClassName": "gp3",
+		"persistentVolumes.pv-1.accessModes[0]":   "ReadWriteMany",
+		"persistentVolumes.pv-1.volumeMode":       "Filesystem",
+		"persistentVolumes.pv-1.keep":             "true",
+		"persistentVolumes.pv-2.mountPath":        "/mnt/path/2",
+		"persistentVolumes.pv-2.size":             "1Gi",
+		"replicaCount":                            "1",
+	}
+
+	claims := renderK8SServicePersistentVolumeClaimsWithSetValues(t, setValues)
+	require.Equal(t, len(claims), 2)
+
+	claim := claims[0]
+	assert.Equal(t, "claim-1", claim.Name)
+	assert.Equal(t, "keep", claim.Annotations["helm.sh/resource-policy"])
+	assert.Equal(t, []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany}, claim.Spec.AccessModes)
+	require.NotNil(t, claim.Spec.StorageClassName)
+	assert.Equal(t, "gp3", *claim.Spec.StorageClassName)
+	require.NotNil(t, claim.Spec.VolumeMode)
+	assert.Equal(t, corev1.PersistentVolumeFilesystem, *claim.Spec.VolumeMode)
+	assert.Equal(t, resource.MustParse("10Gi"), claim.Spec.Resources.Requests[corev1.ResourceStorage])
+
+	// The claim name defaults to the fullname suffixed with the key, and the access mode to ReadWriteOnce.
+	defaultClaim := claims[1]
+	assert.Equal(t, "pvc-linter-pv-2", defaultClaim.Name)
+	assert.NotContains(t, defaultClaim.Annotations, "helm.sh/resource-policy")
+	assert.Equal(t, []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}, defaultClaim.Spec.AccessModes)
+	assert.Nil(t, defaultClaim.Spec.StorageClassName)
+	assert.Nil(t, defaultClaim.Spec.VolumeMode)
+	assert.Equal(t, resource.MustParse("1Gi"), defaultClaim.Spec.Resources.Requests[corev1.ResourceStorage])
+
+	// Verify that the claims are mounted on the Pod
+	deployment := renderK8SServiceDeploymentWithSetValues(t, setValues)
+	mounts := deployment.Spec.Template.Spec.Containers[0].VolumeMounts
+	require.Equal(t, len(mounts), 2)
+	assert.Equal(t, "pv-1", mounts[0].Name)
+	assert.Equal(t, "/mnt/path/1", mounts[0].MountPath)
+	assert.Equal(t, "pv-2", mounts[1].Name)
+	assert.Equal(t, "/mnt/path/2", mounts[1].MountPath)
+
+	volumes := deployment.Spec.Template.Spec.Volumes
+	require.Equal(t, len(volumes), 2)
+	assert.Equal(t, "claim-1", volumes[0].PersistentVolumeClaim.ClaimName)
+	assert.Equal(t, "deployment-linter-pv-2", volumes[1].PersistentVolumeClaim.ClaimName)
+}
+
+func TestK8SServicePersistentVolumesReadWriteOnceWithMultipleReplicasFails(t *testing.T) {
+	t.Parallel()
+
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name      string
+		setValues map[string]string
+	}{
+		{
+			"default access mode",
+			map[string]string{
+				"persistentVolumes.pv-1.mountPath": "/mnt/path/1",
+				"persistentVolumes.pv-1.size":      "10Gi",
+				"replicaCount":                     "2",
+			},
+		},
+		{
+			"explicit access mode",
+			map[string]string{
+				"persistentVolumes.pv-1.mountPath":      "/mnt/path/1",
+				"persistentVolumes.pv-1.size":           "10Gi",
+				"persistentVolumes.pv-1.accessModes[0]": "ReadWriteOnce",
+				"replicaCount":                          "3",
+			},
+		},
+		{
+			"autoscaler",
+			map[string]string{
+				"persistentVolumes.pv-1.mountPath":    "/mnt/path/1",
+				"persistentVolumes.pv-1.size":         "10Gi",
+				"horizontalPodAutoscaler.enabled":     "true",
+				"horizontalPodAutoscaler.maxReplicas": "3",
+			},
+		},
+		{
+			"blue green",
+			map[string]string{
+				"persistentVolumes.pv-1.mountPath": "/mnt/path/1",
+				"persistentVolumes.pv-1.size":      "10Gi",
+				"blueGreen.enabled":                "true",
+			},
+		},
+		{
+			"canary",
+			map[string]string{
+				"persistentVolumes.pv-1.mountPath": "/mnt/path/1",
+				"persistentVolumes.pv-1.size":      "10Gi",
+				"canary.enabled":                   "true",
+				"canary.containerImage.repository": "nginx",
+				"canary.containerImage.tag":        "1.17.0",
+			},
+		},
+		{
+			"rollout",
+			map[string]string{
+				"persistentVolumes.pv-1.mountPath": "/mnt/path/1",
+				"persistentVolumes.pv-1.size":      "10Gi",
+				"rollout.enabled":                  "true",
+			},
+		},
+		{
+			"daemonset",
+			map[string]string{
+				"persistentVolumes.pv-1.mountPath": "/mnt/path/1",
+				"persistentVolumes.pv-1.size":      "10Gi",
+				"workloadType":                     "DaemonSet",
+			},
+		},
+	}
+
+	for _, testCase := range testCases {
+		// Capture range variable to force scope
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			options := &helm.Options{
+				ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+				SetValues:   testCase.setValues,
+			}
+			_, err := helm.RenderTemplateE(t, options, helmChartPath, "pvc", []string{"templates/persistentvolumeclaims.yaml"})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "ReadWriteOnce")
+		})
+	}
+}
+
+// Test that a ReadWriteOnce claim renders with an autoscaler that never scales beyond a single replica, and that other
+// access modes can be used with multiple Pods
+func TestK8SServicePersistentVolumesReadWriteOnceWithSinglePod(t *testing.T) {
+	t.Parallel()
+
+	claims := renderK8SServicePersistentVolumeClaimsWithSetValues(
+		t,
+		map[string]string{
+			"persistentVolumes.pv-1.mountPath":      "/mnt/path/1",
+			"persistentVolumes.pv-1.size":           "10Gi",
+			"persistentVolumes.pv-2.mountPath":      "/mnt/path/2",
+			"persistentVolumes.pv-2.size":           "10Gi",
+			"persistentVolumes.pv-2.accessModes[0]": "ReadWriteMany",
+			"horizontalPodAutoscaler.enabled":       "true",
+			"horizontalPodAutoscaler.maxReplicas":   "1",
+		},
+	)
+	require.Equal(t, 2, len(claims))
+}
+
+// Test that persistentVolumes entries that do not create the claim require the claimName of the existing claim
+func TestK8SServicePersistentVolumesWithoutSizeRequiresClaimName(t *testing.T) {
+	t.Parallel()
+
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	options := &helm.Options{
+		ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+		SetValues: map[string]string{
+			"persistentVolumes.pv-1.mountPath": "/mnt/path/1",
+		},
+	}
+	_, err = helm.RenderTemplateE(t, options, helmChartPath, "deployment", []string{"templates/deployment.yaml"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "persistentVolumes.pv-1.claimName is required when size is not set")
+}
+
 func TestK8SServiceDeploymentAddingEmptyDirs(t *testing.T) {
 	t.Parallel()
 
@@ -139,3 +339,179 @@ func TestK8SServiceDeploymentAddingTerminationGracePeriod(t *testing.T) {
 	renderedTerminationGracePeriodSeconds := deployment.Spec.Template.Spec.TerminationGracePeriodSeconds
 	require.Equal(t, expectedGracePeriodInt64, *renderedTerminationGracePeriodSeconds)
 }
+
+func TestK8SServiceDeploymentAddingProjectedVolume(t *testing.T) {
+	t.Parallel()
+
+	deployment := renderK8SServiceDeploymentWithSetValues(
+		t,
+		map[string]string{
+			"volumes.tokens.mountPath": "/var/run/secrets/tokens",
+			"volumes.tokens.readOnly":  "true",
+			"volumes.tokens.projected.sources[0].serviceAccountToken.audience":            "vault",
+			"volumes.tokens.projected.sources[0].serviceAccountToken.expirationSeconds":   "3600",
+			"volumes.tokens.projected.sources[0].serviceAccountToken.path":                "vault-token",
+			"volumes.tokens.projected.sources[1].downwardAPI.items[0].path":               "labels",
+			"volumes.tokens.projected.sources[1].downwardAPI.items[0].fieldRef.fieldPath": "metadata.labels",
+			"volumes.tokens.projected.sources[2].configMap.name":                          "app-config",
+			"volumes.tokens.projected.sources[3].secret.name":                             "app-secret",
+			"volumes.tokens.projected.sources[3].secret.items[0].key":                     "token",
+			"volumes.tokens.projected.sources[3].secret.items[0].path":                    "app-token",
+		},
+	)
+
+	// Verify that a read only mount has been created for the projected volume
+	mounts := deployment.Spec.Template.Spec.Containers[0].VolumeMounts
+	require.Equal(t, len(mounts), 1)
+	assert.Equal(t, "tokens", mounts[0].Name)
+	assert.Equal(t, "/var/run/secrets/tokens", mounts[0].MountPath)
+	assert.True(t, mounts[0].ReadOnly)
+
+	// Verify that the projected volume has been declared with all the sources
+	volumes := deployment.Spec.Template.Spec.Volumes
+	require.Equal(t, len(volumes), 1)
+	volume := volumes[0]
+	assert.Equal(t, "tokens", volume.Name)
+	require.NotNil(t, volume.Projected)
+	sources := volume.Projected.Sources
+	require.Equal(t, len(sources), 4)
+
+	require.NotNil(t, sources[0].ServiceAccountToken)
+	assert.Equal(t, "vault", sources[0].ServiceAccountToken.Audience)
+	assert.Equal(t, int64(3600), *sources[0].ServiceAccountToken.ExpirationSeconds)
+	assert.Equal(t, "vault-token", sources[0].ServiceAccountToken.Path)
+
+	require.NotNil(t, sources[1].DownwardAPI)
+	assert.Equal(t, "labels", sources[1].DownwardAPI.Items[0].Path)
+	assert.Equal(t, "metadata.labels", sources[1].DownwardAPI.Items[0].FieldRef.FieldPath)
+
+	require.NotNil(t, sources[2].ConfigMap)
+	assert.Equal(t, "app-config", sources[2].ConfigMap.Name)
+
+	require.NotNil(t, sources[3].Secret)
+	assert.Equal(t, "app-secret", sources[3].Secret.Name)
+	assert.Equal(t, []corev1.KeyToPath{{Key: "token", Path: "app-token"}}, sources[3].Secret.Items)
+}
+
+func TestK8SServiceDeploymentAddingVolumesOfEachType(t *testing.T) {
+	t.Parallel()
+
+	deployment := renderK8SServiceDeploymentWithSetValues(
+		t,
+		map[string]string{
+			"volumes.cache.mountPath":                                                       "/cache",
+			"volumes.cache.subPathExpr":                                                     "$(POD_NAME)",
+			"volumes.cache.emptyDir.medium":                                                 "Memory",
+			"volumes.cache.emptyDir.sizeLimit":                                              "1Gi",
+			"volumes.podinfo.mountPath":                                                     "/etc/podinfo",
+			"volumes.podinfo.downwardAPI.items[0].path":                                     "annotations",
+			"volumes.podinfo.downwardAPI.items[0].fieldRef.fieldPath":                       "metadata.annotations",
+			"volumes.scratch.mountPath":                                                     "/scratch",
+			"volumes.scratch.ephemeral.volumeClaimTemplate.spec.accessModes[0]":             "ReadWriteOnce",
+			"volumes.scratch.ephemeral.volumeClaimTemplate.spec.storageClassName":           "gp3",
+			"volumes.scratch.ephemeral.volumeClaimTemplate.spec.resources.requests.storage": "5Gi",
+			"volumes.shared.mountPath":                                                      "/mnt/shared",
+			"volumes.shared.subPath":                                                        "app",
+			"volumes.shared.readOnly":                                                       "true",
+			"volumes.shared.mountPropagation":                                               "HostToContainer",
+			"volumes.shared.nfs.server":                                                     "nfs.example.com",
+			"volumes.shared.nfs.path":                                                       "/exports",
+		},
+	)
+
+	// The volumes and mounts are rendered in the order of the keys.
+	mounts := deployment.Spec.Template.Spec.Containers[0].VolumeMounts
+	require.Equal(t, len(mounts), 4)
+	volumes := deployment.Spec.Template.Spec.Volumes
+	require.Equal(t, len(volumes), 4)
+
+	// emptyDir with a medium and size limit, mounted with a subPathExpr
+	assert.Equal(t, "cache", mounts[0].Name)
+	assert.Equal(t, "$(POD_NAME)", mounts[0].SubPathExpr)
+	assert.Equal(t, "cache", volumes[0].Name)
+	require.NotNil(t, volumes[0].EmptyDir)
+	assert.Equal(t, corev1.StorageMediumMemory, volumes[0].EmptyDir.Medium)
+	assert.Equal(t, resource.MustParse("1Gi"), *volumes[0].EmptyDir.SizeLimit)
+
+	// downwardAPI
+	assert.Equal(t, "podinfo", mounts[1].Name)
+	assert.Equal(t, "/etc/podinfo", mounts[1].MountPath)
+	require.NotNil(t, volumes[1].DownwardAPI)
+	assert.Equal(t, "metadata.annotations", volumes[1].DownwardAPI.Items[0].FieldRef.FieldPath)
+
+	// generic ephemeral volume
+	assert.Equal(t, "scratch", mounts[2].Name)
+	require.NotNil(t, volumes[2].Ephemeral)
+	claimSpec := volumes[2].Ephemeral.VolumeClaimTemplate.Spec
+	assert.Equal(t, []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce}, claimSpec.AccessModes)
+	assert.Equal(t, "gp3", *claimSpec.StorageClassName)
+	assert.Equal(t, resource.MustParse("5Gi"), claimSpec.Resources.Requests[corev1.ResourceStorage])
+
+	// NFS mounted read only on a sub path with mount propagation
+	assert.Equal(t, "shared", mounts[3].Name)
+	assert.Equal(t, "app", mounts[3].SubPath)
+	assert.True(t, mounts[3].ReadOnly)
+	require.NotNil(t, mounts[3].MountPropagation)
+	assert.Equal(t, corev1.MountPropagationHostToContainer, *mounts[3].MountPropagation)
+	require.NotNil(t, volumes[3].NFS)
+	assert.Equal(t, "nfs.example.com", volumes[3].NFS.Server)
+	assert.Equal(t, "/exports", volumes[3].NFS.Path)
+}
+
+func TestK8SServiceDeploymentVolumesRequireExactlyOneSupportedSource(t *testing.T) {
+	t.Parallel()
+
+	helmChartPath, err := filepath.Abs(filepath.Join("..", "charts", "k8s-service"))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name      string
+		setValues map[string]string
+	}{
+		{
+			"no volume source",
+			map[string]string{"volumes.cache.mountPath": "/cache"},
+		},
+		{
+			"multiple volume sources",
+			map[string]string{
+				"volumes.cache.mountPath":          "/cache",
+				"volumes.cache.emptyDir.sizeLimit": "1Gi",
+				"volumes.cache.nfs.server":         "nfs.example.com",
+			},
+		},
+		{
+			"hostPath volume source, which is set with hostPathVolumes",
+			map[string]string{
+				"volumes.cache.mountPath":     "/cache",
+				"volumes.cache.hostPath.path": "/cache",
+			},
+		},
+		{
+			"unsupported volume source",
+			map[string]string{
+				"volumes.cache.mountPath":                     "/cache",
+				"volumes.cache.awsElasticBlockStore.volumeID": "vol-123",
+			},
+		},
+		{
+			"missing mountPath",
+			map[string]string{"volumes.cache.emptyDir.sizeLimit": "1Gi"},
+		},
+	}
+
+	for _, testCase := range testCases {
+		// Capture range variable to force scope
+		testCase := testCase
+		t.Run(testCase.name, func(t *testing.T) {
+			t.Parallel()
+
+			options := &helm.Options{
+				ValuesFiles: []string{filepath.Join("..", "charts", "k8s-service", "linter_values.yaml")},
+				SetValues:   testCase.setValues,
+			}
+			_, err := helm.RenderTemplateE(t, options, helmChartPath, "deployment", []string{"templates/deployment.yaml"})
+			require.Error(t, err)
+		})
+	}
+}